@@ -2,8 +2,24 @@ package ast
 
 type Stmt interface {
 	Stmt()
+	Range() Span
 }
 
 type Expr interface {
 	Expr()
+	Range() Span
+}
+
+// Span is the range of source text a node was parsed from, given as byte
+// offsets into the source. Start is inclusive and End is exclusive. Use
+// lexer.LineMap to turn an offset into a line and column.
+type Span struct {
+	Start int
+	End   int
+}
+
+// Range returns the span itself. Every node embeds a Span, so this gives all
+// nodes a Range method.
+func (s Span) Range() Span {
+	return s
 }
@@ -1,28 +1,50 @@
 package ast
 
 import (
+	"time"
+
 	"github.com/guthius/vb6/lexer"
 )
 
+// NumberExpr is a numeric literal. Type records the data type VB6 gives the
+// literal, either from its type suffix or from the size of its value.
 type NumberExpr struct {
+	Span
+
 	Value float64
+	Type  DataType
 }
 
 func (n NumberExpr) Expr() {}
 
 type StringExpr struct {
+	Span
+
 	Value string
 }
 
 func (n StringExpr) Expr() {}
 
+// DateExpr is a date literal such as #12/31/1999 11:59 PM#.
+type DateExpr struct {
+	Span
+
+	Value time.Time
+}
+
+func (n DateExpr) Expr() {}
+
 type SymbolExpr struct {
+	Span
+
 	Name string
 }
 
 func (n SymbolExpr) Expr() {}
 
 type BinaryExpr struct {
+	Span
+
 	Left     Expr
 	Operator lexer.Token
 	Right    Expr
@@ -30,14 +52,51 @@ type BinaryExpr struct {
 
 func (n BinaryExpr) Expr() {}
 
+// UnaryExpr is a prefix operator applied to an operand: Not, negation or
+// unary plus.
+type UnaryExpr struct {
+	Span
+
+	Operator lexer.Token
+	Operand  Expr
+}
+
+func (n UnaryExpr) Expr() {}
+
+// GroupExpr is an expression in parentheses. It is kept in the tree so that
+// the span of an expression that starts or ends with one covers the
+// parentheses.
+type GroupExpr struct {
+	Span
+
+	Inner Expr
+}
+
+func (n GroupExpr) Expr() {}
+
 type RangeExpr struct {
+	Span
+
 	LBound Expr
 	UBound Expr
 }
 
 func (n RangeExpr) Expr() {}
 
+// CaseIsExpr is a comparison in a Case clause, as in Case Is > 10. The value
+// being selected on is the implicit left operand.
+type CaseIsExpr struct {
+	Span
+
+	Operator lexer.Token
+	Value    Expr
+}
+
+func (n CaseIsExpr) Expr() {}
+
 type FieldDeclExpr struct {
+	Span
+
 	Identifier string
 	Type       TypeExpr
 	IsArray    bool
@@ -46,17 +105,86 @@ type FieldDeclExpr struct {
 
 func (n FieldDeclExpr) Expr() {}
 
-type CallExpr struct {
+// EnumMemberExpr is a member of an Enum. Value is nil when the member has no
+// initializer; see EnumStmt.Values for its implicit value.
+type EnumMemberExpr struct {
+	Span
+
 	Identifier string
-	Args       []Expr
+	Value      Expr
 }
 
-func (n CallExpr) Expr() {}
+func (n EnumMemberExpr) Expr() {}
+
+// MemberExpr is the access of a member of an object, such as rs.Fields.
+type MemberExpr struct {
+	Span
+
+	Object Expr
+	Member string
+}
+
+func (n MemberExpr) Expr() {}
+
+// ImplicitMemberExpr is a member access without an object, as in .Name = x.
+// It can only appear in a With block, and refers to a member of the object of
+// the innermost one.
+type ImplicitMemberExpr struct {
+	Span
+
+	Member string
+}
+
+func (n ImplicitMemberExpr) Expr() {}
+
+// IndexOrCallExpr is an expression followed by arguments in parentheses. VB6
+// uses the same syntax for indexing an array and for calling a function or
+// property, so the parser cannot tell the two apart.
+type IndexOrCallExpr struct {
+	Span
+
+	Callee Expr
+	Args   []Expr
+}
+
+func (n IndexOrCallExpr) Expr() {}
 
 type ArgExpr struct {
+	Span
+
 	ByRef      bool
 	Identifier string
 	Type       TypeExpr
 }
 
 func (n ArgExpr) Expr() {}
+
+// TypeOfExpr is a TypeOf object Is type test. TypeName may be qualified by a
+// library name, as in VB.TextBox.
+type TypeOfExpr struct {
+	Span
+
+	Object   Expr
+	TypeName string
+}
+
+func (n TypeOfExpr) Expr() {}
+
+// NewExpr creates a new instance of a class, as in Set c = New Collection.
+type NewExpr struct {
+	Span
+
+	TypeName string
+}
+
+func (n NewExpr) Expr() {}
+
+// AddressOfExpr takes the address of a procedure, to pass it as a callback
+// to an API function.
+type AddressOfExpr struct {
+	Span
+
+	Identifier string
+}
+
+func (n AddressOfExpr) Expr() {}
@@ -1,16 +1,63 @@
 package ast
 
+import "github.com/guthius/vb6/lexer"
+
 type BlockStmt []Stmt
 
 func (n BlockStmt) Stmt() {}
 
+// Range returns the span from the start of the first statement to the end of
+// the last one. An empty block has an empty span.
+func (n BlockStmt) Range() Span {
+	if len(n) == 0 {
+		return Span{}
+	}
+	return Span{Start: n[0].Range().Start, End: n[len(n)-1].Range().End}
+}
+
 type ExprStmt struct {
+	Span
+
 	Expr Expr
 }
 
 func (n ExprStmt) Stmt() {}
 
+// AssignStmt assigns a value to a variable, property or array element, as in
+// x = 1.
+type AssignStmt struct {
+	Span
+
+	Target Expr
+	Value  Expr
+}
+
+func (n AssignStmt) Stmt() {}
+
+// LetStmt is an assignment written with the optional Let keyword, as in
+// Let x = 1.
+type LetStmt struct {
+	Span
+
+	Target Expr
+	Value  Expr
+}
+
+func (n LetStmt) Stmt() {}
+
+// SetStmt assigns an object reference, as in Set rs = db.OpenRecordset(sql).
+type SetStmt struct {
+	Span
+
+	Target Expr
+	Value  Expr
+}
+
+func (n SetStmt) Stmt() {}
+
 type ConstDeclStmt struct {
+	Span
+
 	Public     bool
 	Identifier string
 	Value      Expr
@@ -19,6 +66,8 @@ type ConstDeclStmt struct {
 func (n ConstDeclStmt) Stmt() {}
 
 type VarDeclStmt struct {
+	Span
+
 	Public     bool
 	Identifier string
 	Type       TypeExpr
@@ -30,13 +79,72 @@ type VarDeclStmt struct {
 func (n VarDeclStmt) Stmt() {}
 
 type TypeStmt struct {
+	Span
+
 	Identifier string
 	Fields     []FieldDeclExpr
 }
 
 func (n TypeStmt) Stmt() {}
 
+// EnumStmt is an Enum declaration. An Enum without Public or Private is
+// public.
+type EnumStmt struct {
+	Span
+
+	Public     bool
+	Identifier string
+	Members    []EnumMemberExpr
+}
+
+func (n EnumStmt) Stmt() {}
+
+// Values returns the value of each member. A member without an initializer
+// is one more than the member before it, and the first one is 0. When the
+// member before it does not have a plain number as its value, the implicit
+// value is returned as that member + 1.
+func (n EnumStmt) Values() []Expr {
+	values := make([]Expr, len(n.Members))
+	for i, member := range n.Members {
+		switch {
+		case member.Value != nil:
+			values[i] = member.Value
+		case i == 0:
+			values[i] = NumberExpr{Span: member.Span, Value: 0, Type: DtLong}
+		default:
+			if v, ok := numberValue(values[i-1]); ok {
+				values[i] = NumberExpr{Span: member.Span, Value: v + 1, Type: DtLong}
+				break
+			}
+			values[i] = BinaryExpr{
+				Span:     member.Span,
+				Left:     SymbolExpr{Span: member.Span, Name: n.Members[i-1].Identifier},
+				Operator: lexer.Token{Kind: lexer.Add, Value: "+", Start: member.Start, End: member.Start},
+				Right:    NumberExpr{Span: member.Span, Value: 1, Type: DtInteger},
+			}
+		}
+	}
+	return values
+}
+
+// numberValue returns the value of a number literal, which may be negated.
+func numberValue(expr Expr) (float64, bool) {
+	switch e := expr.(type) {
+	case NumberExpr:
+		return e.Value, true
+	case GroupExpr:
+		return numberValue(e.Inner)
+	case UnaryExpr:
+		if v, ok := numberValue(e.Operand); ok && e.Operator.Kind == lexer.Subtract {
+			return -v, true
+		}
+	}
+	return 0, false
+}
+
 type CallStmt struct {
+	Span
+
 	Identifier string
 	Args       []Expr
 }
@@ -44,6 +152,8 @@ type CallStmt struct {
 func (n CallStmt) Stmt() {}
 
 type DeclareStmt struct {
+	Span
+
 	Identifier string
 	Lib        string
 	Alias      string
@@ -53,8 +163,16 @@ type DeclareStmt struct {
 
 func (n DeclareStmt) Stmt() {}
 
+// FunctionStmt is a Function procedure. A procedure without a Public,
+// Private or Friend modifier is public. Friend procedures are only visible
+// inside the project, and are not public. Static procedures keep the values
+// of their local variables between calls.
 type FunctionStmt struct {
+	Span
+
 	Public     bool
+	Friend     bool
+	Static     bool
 	Identifier string
 	Args       []ArgExpr
 	ReturnType TypeExpr
@@ -64,6 +182,8 @@ type FunctionStmt struct {
 func (n FunctionStmt) Stmt() {}
 
 type ElseIfStmt struct {
+	Span
+
 	Condition Expr
 	Body      BlockStmt
 }
@@ -71,6 +191,8 @@ type ElseIfStmt struct {
 func (n ElseIfStmt) Stmt() {}
 
 type IfStmt struct {
+	Span
+
 	Condition Expr
 	Body      BlockStmt
 	ElseIf    []ElseIfStmt
@@ -79,11 +201,53 @@ type IfStmt struct {
 
 func (n IfStmt) Stmt() {}
 
-type ExitFunctionStmt struct{}
+type ExitFunctionStmt struct {
+	Span
+}
 
 func (n ExitFunctionStmt) Stmt() {}
 
+type ExitPropertyStmt struct {
+	Span
+}
+
+func (n ExitPropertyStmt) Stmt() {}
+
+// DoStmt is a Do...Loop. The condition is either tested before every
+// iteration (Do While x ... Loop) or after it (Do ... Loop While x), in which
+// case the body runs at least once. A While condition keeps the loop going
+// while it is true; an Until condition ends the loop once it becomes true.
+// A loop without a condition only ends with Exit Do.
+type DoStmt struct {
+	Span
+
+	Condition  Expr
+	IsPostTest bool
+	IsUntil    bool
+	Body       BlockStmt
+}
+
+func (n DoStmt) Stmt() {}
+
+// WhileStmt is a While...Wend loop, which behaves like Do While...Loop.
+type WhileStmt struct {
+	Span
+
+	Condition Expr
+	Body      BlockStmt
+}
+
+func (n WhileStmt) Stmt() {}
+
+type ExitDoStmt struct {
+	Span
+}
+
+func (n ExitDoStmt) Stmt() {}
+
 type ForStmt struct {
+	Span
+
 	Identifier string
 	Start      Expr
 	End        Expr
@@ -93,7 +257,14 @@ type ForStmt struct {
 
 func (n ForStmt) Stmt() {}
 
+// SubStmt is a Sub procedure. The modifiers are the same as for a
+// FunctionStmt.
 type SubStmt struct {
+	Span
+
+	Public     bool
+	Friend     bool
+	Static     bool
 	Identifier string
 	Args       []ArgExpr
 	Body       BlockStmt
@@ -101,6 +272,97 @@ type SubStmt struct {
 
 func (n SubStmt) Stmt() {}
 
-type OptionExplicitStmt struct{}
+// PropertyStmt is a Property Get, Property Let or Property Set procedure.
+// ReturnType is only set for a Property Get. The modifiers are the same as for a
+// FunctionStmt.
+type PropertyStmt struct {
+	Span
+
+	Kind       PropertyKind
+	Public     bool
+	Friend     bool
+	Static     bool
+	Identifier string
+	Args       []ArgExpr
+	ReturnType TypeExpr
+	Body       BlockStmt
+}
+
+func (n PropertyStmt) Stmt() {}
+
+type OptionExplicitStmt struct {
+	Span
+}
 
 func (n OptionExplicitStmt) Stmt() {}
+
+// LabelStmt is a line label, either a name such as "ErrHandler:" or a line
+// number. Its span includes the colon after the label, if there is one.
+type LabelStmt struct {
+	Span
+
+	Label string
+}
+
+func (n LabelStmt) Stmt() {}
+
+// ConditionalCompilationStmt is an #If ... #End If block. The parser only
+// sees these when the preprocessor was told to keep all branches; otherwise
+// the inactive branches and the directives are removed before parsing.
+type ConditionalCompilationStmt struct {
+	Span
+
+	Condition Expr
+	Body      BlockStmt
+	ElseIf    []ElseIfStmt
+	Else      BlockStmt
+}
+
+func (n ConditionalCompilationStmt) Stmt() {}
+
+// ConditionalConstStmt is a #Const directive, which defines a constant that
+// can only be used in the conditions of #If directives.
+type ConditionalConstStmt struct {
+	Span
+
+	Identifier string
+	Value      Expr
+}
+
+func (n ConditionalConstStmt) Stmt() {}
+
+// WithStmt is a With block. Inside the body, members of Object can be used
+// without naming it, as in .Name = x; see ImplicitMemberExpr.
+type WithStmt struct {
+	Span
+
+	Object Expr
+	Body   BlockStmt
+}
+
+func (n WithStmt) Stmt() {}
+
+// SelectStmt is a Select Case block. Expr is the value that the Case clauses
+// are tested against.
+type SelectStmt struct {
+	Span
+
+	Expr  Expr
+	Cases []CaseClause
+}
+
+func (n SelectStmt) Stmt() {}
+
+// CaseClause is one Case of a Select Case block. Tests holds its
+// comma-separated tests; each is either a value to compare with, a RangeExpr
+// (1 To 5) or a CaseIsExpr (Is > 10). The clause matches if any test does.
+// Case Else has IsElse set and no tests.
+type CaseClause struct {
+	Span
+
+	Tests  []Expr
+	IsElse bool
+	Body   BlockStmt
+}
+
+func (n CaseClause) Stmt() {}
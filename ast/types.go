@@ -10,10 +10,22 @@ const (
 	DtSingle
 	DtDouble
 	DtString
+	DtCurrency
+	DtVariant
 	DtUserDefined
 )
 
+type PropertyKind int
+
+const (
+	PropertyGet PropertyKind = iota
+	PropertyLet
+	PropertySet
+)
+
 type TypeExpr struct {
+	Span
+
 	Type       DataType
 	TypeName   string // only for DtUserDefined
 	IsFixedLen bool
@@ -0,0 +1,60 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// syntheticModule builds a module of roughly the given number of lines, made
+// up of the kind of code found in real VB6 projects.
+func syntheticModule(lines int) string {
+	template := []string{
+		"' Handles player movement",
+		"Public Sub PlayerMove%d(ByVal Index As Long, ByVal Dir As Byte)",
+		"Dim x As Long, y As Long",
+		"    If GetPlayerX(Index) <= 0 And Dir = DIR_LEFT Then",
+		"        Call SendPlayerXY(Index) ' resync",
+		"        Exit Sub",
+		"    ElseIf Player(Index).Moving <> MOVING_WALKING Then",
+		"        Player(Index).Sprite = &HFF& + (x * 2) / 3 - y \\ 4",
+		"    End If",
+		"    For x = 1 To MAX_MAP_ITEMS Step 2",
+		"        s$ = Trim$(Player(Index).Name) & \"\"\" is here\"\"\"",
+		"    Next x",
+		"End Sub",
+		"",
+	}
+
+	var sb strings.Builder
+	for i := 0; i < lines; i += len(template) {
+		for _, line := range template {
+			if strings.Contains(line, "%d") {
+				line = fmt.Sprintf(line, i)
+			}
+			sb.WriteString(line)
+			sb.WriteString("\r\n")
+		}
+	}
+	return sb.String()
+}
+
+func BenchmarkTokenize(b *testing.B) {
+	source := syntheticModule(100_000)
+	b.SetBytes(int64(len(source)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Tokenize(source)
+	}
+}
+
+func BenchmarkTokenizeWithTrivia(b *testing.B) {
+	source := syntheticModule(100_000)
+	b.SetBytes(int64(len(source)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		TokenizeWithOptions(source, Options{Trivia: true})
+	}
+}
@@ -0,0 +1,115 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateLayouts lists the date forms accepted between the '#' delimiters of a
+// date literal. Layouts using a two-digit year are marked so that the year
+// can be mapped using the VB6 rules instead of the Go ones.
+var dateLayouts = []struct {
+	layout    string
+	shortYear bool
+}{
+	{"1/2/2006", false},
+	{"1-2-2006", false},
+	{"2006-1-2", false},
+	{"2006/1/2", false},
+	{"1/2/06", true},
+	{"1-2-06", true},
+	{"January 2, 2006", false},
+	{"Jan 2, 2006", false},
+	{"January 2 2006", false},
+	{"Jan 2 2006", false},
+	{"2 January 2006", false},
+	{"2 Jan 2006", false},
+	{"2-Jan-2006", false},
+	{"2-Jan-06", true},
+}
+
+// timeLayouts lists the time forms accepted in a date literal, either on
+// their own or following a date.
+var timeLayouts = []string{
+	"15:04:05",
+	"15:04",
+	"3:04:05 PM",
+	"3:04 PM",
+	"3:04:05PM",
+	"3:04PM",
+	"3 PM",
+	"3PM",
+}
+
+// ParseDate parses the contents of a date literal, without the surrounding
+// '#' characters. The literal may hold a date, a time or both, such as
+// "12/31/1999 11:59 PM". Like VB6, a time without a date falls on
+// 12/30/1899 and two-digit years 00-29 are read as 2000-2029.
+func ParseDate(s string) (time.Time, error) {
+	s = strings.ToUpper(strings.Join(strings.Fields(s), " "))
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty date literal")
+	}
+
+	if t, ok := parseTime(s); ok {
+		return time.Date(1899, time.December, 30, t.Hour(), t.Minute(), t.Second(), 0, time.UTC), nil
+	}
+
+	for _, d := range dateLayouts {
+		date, rest, ok := parseDatePrefix(s, d.layout)
+		if !ok {
+			continue
+		}
+
+		if d.shortYear {
+			year := date.Year() % 100
+			if year < 30 {
+				year += 2000
+			} else {
+				year += 1900
+			}
+			date = time.Date(year, date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		}
+
+		if rest == "" {
+			return date, nil
+		}
+
+		if t, ok := parseTime(rest); ok {
+			return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date literal #%s#", s)
+}
+
+// parseDatePrefix parses the date at the start of s using the given layout
+// and returns whatever follows it.
+func parseDatePrefix(s string, layout string) (time.Time, string, bool) {
+	if t, err := time.Parse(layout, s); err == nil {
+		return t, "", true
+	}
+
+	// The date can be followed by a time, separated by a single space. Try
+	// every split point; layouts with month names contain spaces themselves.
+	for i := len(s) - 1; i > 0; i-- {
+		if s[i] != ' ' {
+			continue
+		}
+		if t, err := time.Parse(layout, s[:i]); err == nil {
+			return t, s[i+1:], true
+		}
+	}
+
+	return time.Time{}, "", false
+}
+
+func parseTime(s string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,16 @@
+package lexer
+
+import "fmt"
+
+// Diagnostic describes a problem found while tokenizing the source code.
+// The lexer does not stop at the first problem; it records a diagnostic,
+// emits an Illegal token and continues scanning.
+type Diagnostic struct {
+	Message string
+	Line    int
+	Column  int
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", d.Line, d.Column, d.Message)
+}
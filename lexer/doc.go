@@ -43,8 +43,49 @@
 //	EndIf
 //	EOF
 //
-// # Future Enhancements
+// # Source Files
 //
-//   - Add support for Eqv and Imp keywords.
-//   - Improve error handling for invalid characters.
+// The lexer works on UTF-8 text. VB6 saves source files in the ANSI code page
+// of the machine, so files should be decoded with the source package first:
+//
+//	file, err := source.ReadFile("modTypes.bas", source.Options{})
+//	tokens := lexer.Tokenize(file.Text)
+//
+// Offsets on the tokens refer to file.Text; file.OriginalOffset maps them back
+// to the bytes of the file.
+//
+// # Error Handling
+//
+// The lexer never panics on malformed input. Characters it does not
+// recognize are emitted as Illegal tokens and scanning continues, so every
+// problem in a file can be reported at once:
+//
+//	tokens, diagnostics := lexer.TokenizeWithDiagnostics(source)
+//	for _, d := range diagnostics {
+//	    fmt.Println(d)
+//	}
+//
+// # Trivia
+//
+// By default comments, whitespace and line continuations are discarded. Tools
+// that need to reproduce the original source, or that want to look at the
+// comments, can ask the lexer to keep them as trivia on the tokens:
+//
+//	tokens, _ := lexer.TokenizeWithOptions(source, lexer.Options{Trivia: true})
+//
+// # Streaming
+//
+// Tokenize scans the whole source before returning. A Scanner instead
+// produces tokens one at a time, so large files never have to be held as a
+// slice of tokens, and a caller can stop as soon as it has seen enough:
+//
+//	s := lexer.NewScanner(source, lexer.Options{})
+//	for tok := range s.All() {
+//	    if tok.Kind == lexer.LineBreak {
+//	        break
+//	    }
+//	    fmt.Println(tok)
+//	}
+//
+// The parser can consume a Scanner directly with parser.ParseScanner.
 package lexer
@@ -2,20 +2,42 @@ package lexer
 
 import (
 	"unicode"
+	"unicode/utf8"
 )
 
+// byteOrderMark is the UTF-8 encoding of U+FEFF, which editors may put at the
+// start of a file.
+const byteOrderMark = "\uFEFF"
+
 func isWhitespace(c byte) bool {
-	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+	return c == ' ' || c == '\t'
 }
 
-func isLetter(c byte) bool {
-	return unicode.IsLetter(rune(c))
+// isLetter reports whether r is a letter. The source is UTF-8, so letters
+// outside ASCII, such as the accented letters in European identifiers, take
+// more than one byte and must be decoded before they are checked.
+func isLetter(r rune) bool {
+	return unicode.IsLetter(r)
 }
 
 func isDigit(c byte) bool {
-	return unicode.IsDigit(rune(c))
+	return c >= '0' && c <= '9'
+}
+
+func isLetterOrDigitOrUnderscore(r rune) bool {
+	return isLetter(r) || r < utf8.RuneSelf && isDigit(byte(r)) || r == '_'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isOctalDigit(c byte) bool {
+	return c >= '0' && c <= '7'
 }
 
-func isLetterOrDigitOrUnderscore(c byte) bool {
-	return isLetter(c) || isDigit(c) || c == '_'
+// isContinuationByte reports whether c is one of the trailing bytes of a
+// multi-byte UTF-8 character.
+func isContinuationByte(c byte) bool {
+	return c&0xC0 == 0x80
 }
@@ -0,0 +1,151 @@
+package lexer
+
+import "unicode/utf8"
+
+// keywords maps the lower case spelling of every single-word keyword to its
+// token kind. VB6 keywords are case-insensitive, so words are lowered before
+// they are looked up.
+var keywords = map[string]Kind{
+	"addressof": AddressOf,
+	"alias":     Alias,
+	"and":       And,
+	"as":        As,
+	"boolean":   BooleanType,
+	"byref":     ByRef,
+	"byte":      ByteType,
+	"byval":     ByVal,
+	"call":      Call,
+	"case":      Case,
+	"const":     Const,
+	"declare":   Declare,
+	"dim":       Dim,
+	"do":        Do,
+	"doevents":  DoEvents,
+	"double":    DoubleType,
+	"else":      Else,
+	"elseif":    ElseIf,
+	"enum":      Enum,
+	"eqv":       Eqv,
+	"for":       For,
+	"friend":    Friend,
+	"function":  Function,
+	"goto":      GoTo,
+	"if":        If,
+	"imp":       Imp,
+	"integer":   IntegerType,
+	"is":        Is,
+	"let":       Let,
+	"lib":       Lib,
+	"like":      Like,
+	"long":      LongType,
+	"loop":      Loop,
+	"mod":       Modulus,
+	"new":       New,
+	"next":      Next,
+	"not":       Not,
+	"or":        Or,
+	"private":   Private,
+	"public":    Public,
+	"redim":     ReDim,
+	"select":    Select,
+	"set":       Set,
+	"single":    SingleType,
+	"static":    Static,
+	"step":      Step,
+	"string":    StringType,
+	"sub":       Sub,
+	"then":      Then,
+	"to":        To,
+	"type":      Type,
+	"typeof":    TypeOf,
+	"until":     Until,
+	"wend":      Wend,
+	"while":     While,
+	"with":      With,
+	"xor":       Xor,
+}
+
+// keywordKinds holds the token kinds of all keywords, single-word and
+// compound.
+var keywordKinds = func() map[Kind]bool {
+	kinds := make(map[Kind]bool, len(keywords))
+	for _, kind := range keywords {
+		kinds[kind] = true
+	}
+	for _, second := range compoundKeywords {
+		for _, kind := range second {
+			kinds[kind] = true
+		}
+	}
+	return kinds
+}()
+
+// compoundKeywords lists the keywords that consist of two words, such as
+// "End If". They are keyed by the lower case first word and then by the lower
+// case second word. Any amount of spaces or tabs may separate the two words.
+var compoundKeywords = map[string]map[string]Kind{
+	"end": {
+		"enum":     EndEnum,
+		"function": EndFunction,
+		"if":       EndIf,
+		"property": EndProperty,
+		"select":   EndSelect,
+		"sub":      EndSub,
+		"type":     EndType,
+		"with":     EndWith,
+	},
+	"exit": {
+		"do":       ExitDo,
+		"function": ExitFunction,
+		"property": ExitProperty,
+	},
+	"option": {
+		"explicit": OptionExplicit,
+	},
+	"property": {
+		"get": PropertyGet,
+		"let": PropertyLet,
+		"set": PropertySet,
+	},
+}
+
+// directives lists the conditional compilation directives, keyed by the lower
+// case word that follows the '#'. #End If is the only directive of two words.
+var directives = map[string]Kind{
+	"if":     HashIf,
+	"elseif": HashElseIf,
+	"else":   HashElse,
+	"const":  HashConst,
+}
+
+var compoundDirectives = map[string]map[string]Kind{
+	"end": {
+		"if": HashEndIf,
+	},
+}
+
+// maxKeywordLen is the length of the longest word in the keyword tables.
+// Longer words are always identifiers.
+const maxKeywordLen = 9
+
+// foldKeyword writes the lower case form of the word to buf and returns it,
+// without allocating. It returns nil if the word cannot be part of a keyword
+// because it is too long or contains non-ASCII characters.
+//
+// Looking up the result as keywords[string(folded)] does not allocate either.
+func foldKeyword(buf *[maxKeywordLen]byte, word string) []byte {
+	if len(word) > maxKeywordLen {
+		return nil
+	}
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		if c >= utf8.RuneSelf {
+			return nil
+		}
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		buf[i] = c
+	}
+	return buf[:len(word)]
+}
@@ -0,0 +1,82 @@
+package lexer
+
+type tokenSpec struct {
+	String string
+	Kind   Kind
+}
+
+// tokenSpecs is a list of all the operators and punctuation that the lexer
+// can recognize. Keywords are not listed here; they are scanned as words and
+// classified using the keywords table.
+//
+// The specs are compiled into a trie, so the order of the list does not
+// matter: when the lexer finds a '>' character, it will match the '>=' token
+// if it can and fall back to the '>' token otherwise.
+var tokenSpecs = []tokenSpec{
+	{String: "<>", Kind: NotEqual},
+	{String: ">=", Kind: GreaterThanOrEqual},
+	{String: "<=", Kind: LessThanOrEqual},
+	{String: "\r\n", Kind: LineBreak},
+	{String: "\n", Kind: LineBreak},
+	{String: "\r", Kind: LineBreak},
+	{String: ".", Kind: Dot},
+	{String: ",", Kind: Comma},
+	{String: ":", Kind: Colon},
+	{String: "+", Kind: Add},
+	{String: "-", Kind: Subtract},
+	{String: "*", Kind: Multiply},
+	{String: "/", Kind: Divide},
+	{String: "\\", Kind: DivideInt},
+	{String: "^", Kind: Exponent},
+	{String: "&", Kind: Concat},
+	{String: "(", Kind: LParen},
+	{String: ")", Kind: RParen},
+	{String: "=", Kind: Equal},
+	{String: ">", Kind: GreaterThan},
+	{String: "<", Kind: LessThan},
+	{String: "#", Kind: FileNumber},
+}
+
+// operatorNode is a node in the trie of operators and punctuation. Every edge
+// is a character; a node that completes a token has terminal set.
+type operatorNode struct {
+	kind     Kind
+	terminal bool
+	next     [128]*operatorNode
+}
+
+var operatorTrie = buildOperatorTrie(tokenSpecs)
+
+func buildOperatorTrie(specs []tokenSpec) *operatorNode {
+	root := &operatorNode{}
+	for _, spec := range specs {
+		node := root
+		for i := 0; i < len(spec.String); i++ {
+			c := spec.String[i]
+			if node.next[c] == nil {
+				node.next[c] = &operatorNode{}
+			}
+			node = node.next[c]
+		}
+		node.kind = spec.Kind
+		node.terminal = true
+	}
+	return root
+}
+
+// matchOperator walks the trie along s and returns the kind and length of the
+// longest operator that s starts with. The length is 0 if there is no match.
+func matchOperator(s string) (Kind, int) {
+	kind, n := Illegal, 0
+	node := operatorTrie
+	for i := 0; i < len(s) && s[i] < 128; i++ {
+		node = node.next[s[i]]
+		if node == nil {
+			break
+		}
+		if node.terminal {
+			kind, n = node.kind, i+1
+		}
+	}
+	return kind, n
+}
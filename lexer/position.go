@@ -0,0 +1,59 @@
+package lexer
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// LineMap maps byte offsets in a source file to the line and column numbers
+// reported on tokens. It is meant for turning the byte offsets stored on
+// tokens and AST nodes back into positions that can be shown to a user.
+type LineMap struct {
+	source string
+
+	// lines holds the byte offset at which every line starts.
+	lines []int
+}
+
+// NewLineMap builds a LineMap for the given source. Like the lexer, it
+// accepts CRLF, LF and lone CR line endings and does not count a leading
+// byte order mark as a column.
+func NewLineMap(source string) *LineMap {
+	lines := []int{0}
+	if strings.HasPrefix(source, byteOrderMark) {
+		lines[0] = len(byteOrderMark)
+	}
+	for i := 0; i < len(source); i++ {
+		switch {
+		case source[i] == '\n':
+			lines = append(lines, i+1)
+		case source[i] == '\r' && (i+1 == len(source) || source[i+1] != '\n'):
+			lines = append(lines, i+1)
+		}
+	}
+	return &LineMap{source: source, lines: lines}
+}
+
+// Position returns the 1-based line and column of the given byte offset.
+// Columns count characters, not bytes. Offsets past the end of the source are
+// reported on the last line.
+func (m *LineMap) Position(offset int) (line, column int) {
+	offset = min(offset, len(m.source))
+	i := max(sort.Search(len(m.lines), func(i int) bool { return m.lines[i] > offset })-1, 0)
+	if offset < m.lines[i] {
+		return i + 1, 1
+	}
+	return i + 1, utf8.RuneCountInString(m.source[m.lines[i]:offset]) + 1
+}
+
+// Offset returns the byte offset of the given 1-based line and column. It is
+// the inverse of Position.
+func (m *LineMap) Offset(line, column int) int {
+	offset := m.lines[line-1]
+	for ; column > 1 && offset < len(m.source); column-- {
+		_, size := utf8.DecodeRuneInString(m.source[offset:])
+		offset += size
+	}
+	return offset
+}
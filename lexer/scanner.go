@@ -0,0 +1,65 @@
+package lexer
+
+import "iter"
+
+// Scanner produces tokens one at a time, as they are asked for. Unlike
+// Tokenize it never holds more than a couple of tokens in memory, and a
+// caller that only needs the start of a file can simply stop asking.
+//
+//	s := lexer.NewScanner(source, lexer.Options{})
+//	for tok := range s.All() {
+//	    fmt.Println(tok)
+//	}
+type Scanner struct {
+	lex *lexer
+	eof Token
+}
+
+// NewScanner creates a scanner for the given source code.
+func NewScanner(source string, options Options) *Scanner {
+	l := newLexer(source, options)
+	l.skipByteOrderMark()
+	return &Scanner{lex: l}
+}
+
+// Next returns the next token. Once the end of the source has been reached it
+// keeps returning the EOF token.
+func (s *Scanner) Next() Token {
+	l := s.lex
+
+	// A token is only handed out once the token after it has been scanned, so
+	// that all of its trailing trivia has been attached.
+	for len(l.Tokens) < 2 && l.step() {
+	}
+
+	if len(l.Tokens) == 0 {
+		return s.eof
+	}
+
+	tok := l.Tokens[0]
+	n := copy(l.Tokens, l.Tokens[1:])
+	l.Tokens = l.Tokens[:n]
+	if tok.Kind == EOF {
+		s.eof = tok
+	}
+	return tok
+}
+
+// All returns an iterator over the remaining tokens, up to and including the
+// EOF token.
+func (s *Scanner) All() iter.Seq[Token] {
+	return func(yield func(Token) bool) {
+		for {
+			tok := s.Next()
+			if !yield(tok) || tok.Kind == EOF {
+				return
+			}
+		}
+	}
+}
+
+// Diagnostics returns the problems found in the part of the source that has
+// been scanned so far.
+func (s *Scanner) Diagnostics() []Diagnostic {
+	return s.lex.Diagnostics
+}
@@ -0,0 +1,59 @@
+package lexer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScannerNext(t *testing.T) {
+	s := NewScanner("x = 1\n", Options{})
+
+	var tokens []Token
+	for range 5 {
+		tokens = append(tokens, s.Next())
+	}
+
+	// Once the end is reached, Next keeps returning EOF.
+	assertKinds(t, tokens, Identifier, Equal, Number, LineBreak, EOF)
+	if tok := s.Next(); tok.Kind != EOF || tok.Start != 6 {
+		t.Errorf("expected EOF at offset 6, got %v at %d", tok, tok.Start)
+	}
+}
+
+func TestScannerStopEarly(t *testing.T) {
+	s := NewScanner("Attribute VB_Name = \"modTypes\"\nDim x ?\n", Options{})
+
+	var tokens []Token
+	for tok := range s.All() {
+		if tok.Kind == LineBreak {
+			break
+		}
+		tokens = append(tokens, tok)
+	}
+
+	assertKinds(t, tokens, Identifier, Identifier, Equal, String)
+	if len(s.Diagnostics()) != 0 {
+		t.Errorf("expected the rest of the source to be left unscanned, got %v", s.Diagnostics())
+	}
+}
+
+func TestScannerMatchesTokenize(t *testing.T) {
+	source := "\uFEFF' header\r\nPrivate Sub Foo(a%) ' trailing\r\n  x = a _\r\n    + 1 ?\r\nEnd Sub"
+
+	for _, options := range []Options{{}, {Trivia: true}} {
+		expected, expectedDiagnostics := TokenizeWithOptions(source, options)
+
+		s := NewScanner(source, options)
+		var tokens []Token
+		for tok := range s.All() {
+			tokens = append(tokens, tok)
+		}
+
+		if !reflect.DeepEqual(tokens, expected) {
+			t.Errorf("trivia %v: expected %v, got %v", options.Trivia, expected, tokens)
+		}
+		if !reflect.DeepEqual(s.Diagnostics(), expectedDiagnostics) {
+			t.Errorf("trivia %v: expected diagnostics %v, got %v", options.Trivia, expectedDiagnostics, s.Diagnostics())
+		}
+	}
+}
@@ -2,234 +2,604 @@ package lexer
 
 import (
 	"fmt"
+	"strings"
+	"unicode/utf8"
 )
 
+// Options controls optional behavior of the lexer.
+type Options struct {
+	// Trivia makes the lexer record whitespace, comments and line
+	// continuations on the tokens, so the source can be reproduced exactly.
+	Trivia bool
+}
+
 type lexer struct {
-	Tokens []Token
-	Source string
-	Pos    int
-	Line   int
-	Column int
+	// Tokens holds the tokens that have been scanned but not handed out yet.
+	// The most recent token stays here until the next one has been scanned,
+	// because trailing trivia may still be attached to it.
+	Tokens      []Token
+	Diagnostics []Diagnostic
+	Options     Options
+	Source      string
+	Pos         int
+	Line        int
+	Column      int
+
+	// Pending holds the trivia that will become the leading trivia of the
+	// next token.
+	Pending []Trivia
+
+	// StartPos, StartLine and StartColumn hold the position of the token
+	// being scanned.
+	StartPos    int
+	StartLine   int
+	StartColumn int
+
+	// Done is set once the EOF token has been emitted.
+	Done bool
 }
 
-func newLexer(source string) *lexer {
+func newLexer(source string, options Options) *lexer {
 	return &lexer{
-		Tokens: make([]Token, 0),
-		Source: source,
-		Pos:    0,
-		Line:   1,
-		Column: 1,
+		Tokens:      make([]Token, 0, 2),
+		Diagnostics: make([]Diagnostic, 0),
+		Options:     options,
+		Source:      source,
+		Pos:         0,
+		Line:        1,
+		Column:      1,
 	}
 }
 
+// Tokenize breaks the source code into tokens. Characters the lexer does not
+// recognize are returned as Illegal tokens; use TokenizeWithDiagnostics to
+// find out what went wrong.
 func Tokenize(source string) []Token {
-	l := newLexer(source)
-	l.tokenize()
-	return l.Tokens
+	tokens, _ := TokenizeWithDiagnostics(source)
+	return tokens
+}
+
+// TokenizeWithDiagnostics breaks the source code into tokens and returns
+// every problem found along the way. Scanning continues after an error, so
+// all problems in the source are reported at once.
+func TokenizeWithDiagnostics(source string) ([]Token, []Diagnostic) {
+	return TokenizeWithOptions(source, Options{})
+}
+
+// TokenizeWithOptions is like TokenizeWithDiagnostics, but allows optional
+// behavior such as recording trivia to be enabled.
+func TokenizeWithOptions(source string, options Options) ([]Token, []Diagnostic) {
+	l := newLexer(source, options)
+	l.skipByteOrderMark()
+
+	// Typical VB6 code has a token for every four to five bytes of source.
+	// Sizing the slice up front saves copying it over and over as it grows.
+	// Since nothing is handed out until the end, the tokens simply pile up
+	// in the queue rather than going through a Scanner one at a time.
+	l.Tokens = make([]Token, 0, len(source)/4)
+	for l.step() {
+	}
+
+	return l.Tokens, l.Diagnostics
 }
 
 func (l *lexer) add(kind Kind, value string) {
-	// If the previous token is a line continuation token, we remove it.
-	if len(l.Tokens) > 0 && l.Tokens[len(l.Tokens)-1].Kind == LineContinuation {
-		l.Tokens = l.Tokens[:len(l.Tokens)-1]
+	l.emit(Token{Kind: kind, Value: value})
+}
+
+// emit appends the token to the list, stamping it with the position and the
+// raw source text of the token being scanned. It must be called after the
+// token has been consumed.
+func (l *lexer) emit(tok Token) {
+	tok.Raw = l.Source[l.StartPos:l.Pos]
+	tok.Start = l.StartPos
+	tok.End = l.Pos
+	tok.Line = l.StartLine
+	tok.Column = l.StartColumn
+	tok.LeadingTrivia = l.Pending
+	l.Pending = nil
+	l.Tokens = append(l.Tokens, tok)
+}
+
+// addTrivia records the source text from the start of the current token up to
+// the current position as trivia. Whitespace and comments that follow a token
+// on the same line become trailing trivia of that token; everything else is
+// kept as leading trivia for the next token.
+func (l *lexer) addTrivia(kind TriviaKind) {
+	if !l.Options.Trivia {
+		return
+	}
+
+	text := l.Source[l.StartPos:l.Pos]
+	trivia := &l.Pending
+	if last := len(l.Tokens) - 1; len(l.Pending) == 0 && last >= 0 && l.Tokens[last].Kind != LineBreak && kind != LineContinuationTrivia {
+		trivia = &l.Tokens[last].TrailingTrivia
+	}
+
+	// Consecutive whitespace characters are merged into a single trivia.
+	if n := len(*trivia); n > 0 && kind == WhitespaceTrivia && (*trivia)[n-1].Kind == WhitespaceTrivia {
+		(*trivia)[n-1].Text += text
+		return
+	}
+
+	*trivia = append(*trivia, Trivia{Kind: kind, Text: text})
+}
 
-		// If the current token is a line break token, we consume it and don't it to the list.
-		if kind == LineBreak {
-			return
+// step scans the next token or piece of trivia. The first character decides
+// what follows: words are scanned whole and then looked up in the keyword
+// table, and operators are matched using the operator trie. Once the end of
+// the source is reached, step emits the EOF token; after that it returns
+// false.
+func (lex *lexer) step() bool {
+	if lex.Pos >= len(lex.Source) {
+		if lex.Done {
+			return false
 		}
+		lex.markStart()
+		lex.add(EOF, "")
+		lex.Done = true
+		return true
 	}
 
-	l.Tokens = append(l.Tokens, Token{
-		Kind:   kind,
-		Value:  value,
-		Line:   l.Line,
-		Column: l.Column,
-	})
+	lex.markStart()
+
+	c := lex.Source[lex.Pos]
+	switch {
+	case isWhitespace(c):
+		lex.skipWhitespace()
+	case isLetter(lex.runeAt(0)):
+		lex.tokenizeIdentifier()
+	case lex.atNumber():
+		lex.tokenizeNumber()
+	case c == '\'':
+		lex.skipComment()
+	case c == '"':
+		lex.tokenizeString()
+	case c == '[':
+		lex.tokenizeBracketedIdentifier()
+	case c == '#' && lex.tokenizeDirective():
+	case c == '#' && lex.tokenizeDate():
+	case c == '_' && lex.skipLineContinuation():
+	case lex.tokenizeOperator():
+	default:
+		lex.tokenizeIllegal()
+	}
+	return true
 }
 
-type tokenSpec struct {
-	String string
-	Kind   Kind
+func (l *lexer) skipWhitespace() {
+	for l.Pos < len(l.Source) && isWhitespace(l.Source[l.Pos]) {
+		l.advance()
+	}
+	l.addTrivia(WhitespaceTrivia)
 }
 
-// tokenSpecs is a list of all the tokens that the lexer can recognize.
-// Tokens must be ordered from the longest to the shortest. This is important
-// because the lexer will try to match the longest token first.
-//
-// For example, if the lexer
-// finds a '>' character, it will first check if it can match it with the '>='
-// token before matching it with the '>' token.
-var tokenSpecs = []tokenSpec{
-	{String: "Option Explicit", Kind: OptionExplicit},
-	{String: "Exit Function", Kind: ExitFunction},
-	{String: "End Function", Kind: EndFunction},
-	{String: "End Select", Kind: EndSelect},
-	{String: "DoEvents", Kind: DoEvents},
-	{String: "End Enum", Kind: EndEnum},
-	{String: "End Type", Kind: EndType},
-	{String: "End With", Kind: EndWith},
-	{String: "Function", Kind: Function},
-	{String: "Boolean", Kind: BooleanType},
-	{String: "Declare", Kind: Declare},
-	{String: "End Sub", Kind: EndSub},
-	{String: "Integer", Kind: IntegerType},
-	{String: "Private", Kind: Private},
-	{String: "Double", Kind: DoubleType},
-	{String: "ElseIf", Kind: ElseIf},
-	{String: "End If", Kind: EndIf},
-	{String: "Public", Kind: Public},
-	{String: "Select", Kind: Select},
-	{String: "Single", Kind: SingleType},
-	{String: "String", Kind: StringType},
-	{String: "Alias", Kind: Alias},
-	{String: "ByRef", Kind: ByRef},
-	{String: "ByVal", Kind: ByVal},
-	{String: "Const", Kind: Const},
-	{String: "ReDim", Kind: ReDim},
-	{String: "Until", Kind: Until},
-	{String: "While", Kind: While},
-	{String: "Byte", Kind: ByteType},
-	{String: "Call", Kind: Call},
-	{String: "Case", Kind: Case},
-	{String: "Else", Kind: Else},
-	{String: "Enum", Kind: Enum},
-	{String: "GoTo", Kind: GoTo},
-	{String: "Long", Kind: LongType},
-	{String: "Loop", Kind: Loop},
-	{String: "Next", Kind: Next},
-	{String: "Step", Kind: Step},
-	{String: "Then", Kind: Then},
-	{String: "Type", Kind: Type},
-	{String: "Wend", Kind: Wend},
-	{String: "With", Kind: With},
-	{String: "And", Kind: And},
-	{String: "Dim", Kind: Dim},
-	{String: "For", Kind: For},
-	{String: "Lib", Kind: Lib},
-	{String: "Mod", Kind: Modulus},
-	{String: "Not", Kind: Not},
-	{String: "Sub", Kind: Sub},
-	{String: "Xor", Kind: Xor},
-	{String: "As ", Kind: As},
-	{String: "Do ", Kind: Do},
-	{String: "If ", Kind: If},
-	{String: "Or", Kind: Or},
-	{String: "To", Kind: To},
-	{String: "<>", Kind: NotEqual},
-	{String: ">=", Kind: GreaterThanOrEqual},
-	{String: "<=", Kind: LessThanOrEqual},
-	{String: "\n", Kind: LineBreak},
-	{String: ".", Kind: Dot},
-	{String: "_", Kind: LineContinuation},
-	{String: ",", Kind: Comma},
-	{String: "+", Kind: Add},
-	{String: "-", Kind: Subtract},
-	{String: "*", Kind: Multiply},
-	{String: "/", Kind: Divide},
-	{String: "\\", Kind: DivideInt},
-	{String: "^", Kind: Exponent},
-	{String: "&", Kind: Concat},
-	{String: "(", Kind: LParen},
-	{String: ")", Kind: RParen},
-	{String: "=", Kind: Equal},
-	{String: ">", Kind: GreaterThan},
-	{String: "<", Kind: LessThan},
-	{String: "#", Kind: FileNumber},
-}
-
-func (lex *lexer) tokenize() {
-	for lex.Pos < len(lex.Source) {
-		// Rem indicates a comment, but it can only be used at the beginning of a line.
-		// So it must either be the first token in the source, or it must be preceded by a line break.
-		if len(lex.Source)-lex.Pos >= 3 && lex.Source[lex.Pos:lex.Pos+3] == "Rem" && (len(lex.Tokens) == 0 || lex.Tokens[len(lex.Tokens)-1].Kind == LineBreak) {
-			lex.skipComment(3)
-			continue
-		}
+// tokenizeOperator scans the longest operator or punctuation token at the
+// current position. It returns false if there is none.
+func (l *lexer) tokenizeOperator() bool {
+	kind, n := matchOperator(l.Source[l.Pos:])
+	if n == 0 {
+		return false
+	}
 
-		c := lex.Source[lex.Pos]
-		tokenFound := false
-		for _, tokenSpec := range tokenSpecs {
-			tokenLen := len(tokenSpec.String)
-			if tokenLen > len(lex.Source)-lex.Pos {
-				continue
-			}
-			if lex.Source[lex.Pos:lex.Pos+tokenLen] == tokenSpec.String {
-				lex.add(tokenSpec.Kind, tokenSpec.String)
-				lex.advanceN(tokenLen)
-				tokenFound = true
-				break
-			}
-		}
+	l.advanceN(n)
+	l.add(kind, l.Source[l.StartPos:l.Pos])
+	return true
+}
 
-		if tokenFound {
-			continue
-		}
+// skipComment skips the rest of the line, starting at the comment marker
+// (either ' or Rem) at the start of the current token.
+func (l *lexer) skipComment() {
+	for l.Pos < len(l.Source) && l.Source[l.Pos] != '\n' && l.Source[l.Pos] != '\r' {
+		l.advance()
+	}
+	l.addTrivia(CommentTrivia)
+}
 
-		if lex.Pos < len(lex.Source) && isWhitespace(lex.Source[lex.Pos]) {
-			lex.advance()
-			continue
+// skipLineContinuation skips a line continuation: an underscore followed by
+// optional spaces and a line break. The statement carries on at the next line
+// as if the line break were not there. It returns false if the underscore is
+// not at the end of the line.
+func (l *lexer) skipLineContinuation() bool {
+	end := l.Pos + 1
+	for end < len(l.Source) && (l.Source[end] == ' ' || l.Source[end] == '\t') {
+		end++
+	}
+	switch {
+	case end == len(l.Source):
+	case l.Source[end] == '\n':
+		end++
+	case l.Source[end] == '\r':
+		end++
+		if end < len(l.Source) && l.Source[end] == '\n' {
+			end++
 		}
+	default:
+		return false
+	}
+
+	l.advanceN(end - l.Pos)
+	l.addTrivia(LineContinuationTrivia)
+	return true
+}
 
-		switch c {
-		case '\'':
-			lex.skipComment(1)
-		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-			lex.tokenizeNumber()
-		case '"':
-			lex.tokenizeString()
-		default:
-			lex.tokenizeIdentifier()
+// atNumber reports whether a numeric literal starts at the current position.
+// Besides a digit this can be a decimal point followed by a digit (.5) or a
+// hexadecimal or octal prefix followed by a valid digit (&HFF, &O17). A lone
+// '&' is the concatenation operator.
+func (l *lexer) atNumber() bool {
+	c := l.peekAt(0)
+	switch {
+	case isDigit(c):
+		return true
+	case c == '.':
+		return isDigit(l.peekAt(1))
+	case c == '&':
+		switch l.peekAt(1) {
+		case 'H', 'h':
+			return isHexDigit(l.peekAt(2))
+		case 'O', 'o':
+			return isOctalDigit(l.peekAt(2))
 		}
 	}
-	lex.add(EOF, "")
+	return false
 }
 
-func (l *lexer) skipComment(skip int) {
-	l.Pos += skip
-	for l.Pos < len(l.Source) && l.Source[l.Pos] != '\n' && l.Source[l.Pos] != '\r' {
+// tokenizeNumber scans a numeric literal. VB6 supports the following forms:
+//
+//	123   1.5   .5   1E+10   1.5D-3   &HFF   &O17
+//
+// Any of them may be followed by a type suffix, although hexadecimal and
+// octal literals only accept the Integer (%) and Long (&) suffixes.
+func (l *lexer) tokenizeNumber() {
+	if l.peekAt(0) == '&' {
+		l.tokenizeRadixNumber()
+		return
+	}
+
+	start := l.Pos
+	l.skipDigits(isDigit)
+	isFloat := false
+	if l.peekAt(0) == '.' {
+		isFloat = true
 		l.advance()
+		l.skipDigits(isDigit)
+	}
+
+	switch l.peekAt(0) {
+	case 'E', 'e', 'D', 'd':
+		sign := l.peekAt(1) == '+' || l.peekAt(1) == '-'
+		if isDigit(l.peekAt(1)) || sign && isDigit(l.peekAt(2)) {
+			isFloat = true
+			l.advanceN(2)
+			l.skipDigits(isDigit)
+		}
 	}
+
+	value := l.Source[start:l.Pos]
+	suffix := l.scanNumberSuffix()
+	if isFloat && (suffix == IntegerSuffix || suffix == LongSuffix) {
+		l.errorf("invalid type suffix '%c' on floating-point literal %s", suffix, value)
+	}
+
+	l.emit(Token{Kind: Number, Value: value, Radix: 10, Suffix: suffix})
 }
 
-func (l *lexer) tokenizeNumber() {
+func (l *lexer) tokenizeRadixNumber() {
+	radix, isValid := 16, isHexDigit
+	if c := l.peekAt(1); c == 'O' || c == 'o' {
+		radix, isValid = 8, isOctalDigit
+	}
+	l.advanceN(2)
+
 	start := l.Pos
-	for l.Pos < len(l.Source) && isDigit(l.Source[l.Pos]) {
+	l.skipDigits(isValid)
+	value := l.Source[start:l.Pos]
+
+	suffix := l.scanNumberSuffix()
+	if suffix != NoSuffix && suffix != IntegerSuffix && suffix != LongSuffix {
+		l.errorf("invalid type suffix '%c' on %s", suffix, l.Source[start-2:l.Pos])
+	}
+
+	l.emit(Token{Kind: Number, Value: value, Radix: radix, Suffix: suffix})
+}
+
+// tokenizeDirective tries to scan a conditional compilation directive such as
+// #If or #End If. Directives must be the first thing on a line. It returns
+// false, leaving the lexer where it was, if there is no directive here.
+func (l *lexer) tokenizeDirective() bool {
+	if len(l.Tokens) > 0 && l.Tokens[len(l.Tokens)-1].Kind != LineBreak {
+		return false
+	}
+
+	l.advance()
+	var buf [maxKeywordLen]byte
+	word := string(foldKeyword(&buf, l.scanWord()))
+
+	kind, ok := directives[word]
+	if second, compound := compoundDirectives[word]; compound {
+		kind, ok = l.scanCompoundKeyword(second)
+	}
+	if !ok {
+		l.Pos, l.Line, l.Column = l.StartPos, l.StartLine, l.StartColumn
+		return false
+	}
+
+	l.add(kind, l.Source[l.StartPos:l.Pos])
+	return true
+}
+
+// tokenizeDate tries to scan a date literal such as #12/31/1999 11:59 PM#.
+// The '#' character also prefixes file numbers (Print #1, x), so the text up
+// to the next '#' on the same line is only taken as a date literal if it
+// holds a valid date or time. It returns false if no date literal was found.
+func (l *lexer) tokenizeDate() bool {
+	end := l.Pos + 1
+	for end < len(l.Source) && l.Source[end] != '#' && l.Source[end] != '\n' && l.Source[end] != '\r' {
+		end++
+	}
+	if end >= len(l.Source) || l.Source[end] != '#' {
+		return false
+	}
+
+	value := l.Source[l.Pos+1 : end]
+	if _, err := ParseDate(value); err != nil {
+		return false
+	}
+
+	l.advanceN(end + 1 - l.Pos)
+	l.add(DateLiteral, value)
+	return true
+}
+
+func (l *lexer) skipDigits(isValid func(byte) bool) {
+	for l.Pos < len(l.Source) && isValid(l.Source[l.Pos]) {
+		l.advance()
+	}
+}
+
+// scanNumberSuffix consumes the type suffix of a numeric literal, if any.
+func (l *lexer) scanNumberSuffix() TypeSuffix {
+	switch suffix := TypeSuffix(l.peekAt(0)); suffix {
+	case IntegerSuffix, LongSuffix, SingleSuffix, DoubleSuffix, CurrencySuffix:
 		l.advance()
+		return suffix
+	}
+	return NoSuffix
+}
+
+// runeAt decodes the character at the given byte offset from the current
+// position. It returns utf8.RuneError past the end of the source.
+func (l *lexer) runeAt(offset int) rune {
+	if l.Pos+offset >= len(l.Source) {
+		return utf8.RuneError
 	}
-	l.add(Number, l.Source[start:l.Pos])
+	r, _ := utf8.DecodeRuneInString(l.Source[l.Pos+offset:])
+	return r
 }
 
+// peekAt returns the character at the given offset from the current
+// position, or 0 if the offset is past the end of the source.
+func (l *lexer) peekAt(offset int) byte {
+	if l.Pos+offset >= len(l.Source) {
+		return 0
+	}
+	return l.Source[l.Pos+offset]
+}
+
+// tokenizeString scans a string literal. A quote inside a string is written
+// as two quotes ("He said ""hi"""), so the Value of the token holds the
+// unescaped text while Raw holds the literal as written. Strings cannot span
+// lines; a string that is not closed before the end of the line is reported
+// at its opening quote.
 func (l *lexer) tokenizeString() {
 	l.advance()
-	start := l.Pos
-	for l.Pos < len(l.Source) && l.Source[l.Pos] != '"' {
+
+	var value strings.Builder
+	for {
+		if l.Pos >= len(l.Source) || l.Source[l.Pos] == '\n' || l.Source[l.Pos] == '\r' {
+			l.errorf("unterminated string literal")
+			break
+		}
+
+		c := l.Source[l.Pos]
 		l.advance()
+		if c == '"' {
+			if l.peekAt(0) != '"' {
+				break
+			}
+			l.advance()
+		}
+		value.WriteByte(c)
 	}
-	l.add(String, l.Source[start:l.Pos])
-	l.advance()
+
+	l.add(String, value.String())
 }
 
+// tokenizeIdentifier scans a whole word and then classifies it as either a
+// keyword or an identifier. Scanning the whole word first makes sure that
+// identifiers such as "Order" or "Typeface" are not split into keywords.
 func (l *lexer) tokenizeIdentifier() {
 	start := l.Pos
-	if !isLetter(l.Source[l.Pos]) {
-		panic(fmt.Sprintf("unexpected character '%c' in identifier near %s", l.Source[l.Pos], l.Source[l.Pos:l.Pos+10]))
+	word := l.scanWord()
+	var buf [maxKeywordLen]byte
+	lower := foldKeyword(&buf, word)
+
+	// Rem indicates a comment, but it can only be used where a statement can
+	// start: at the beginning of a line or after a colon.
+	if string(lower) == "rem" && l.atStmtStart() {
+		l.skipComment()
+		return
+	}
+
+	// The word after a dot names a member, and any keyword can be used as a
+	// member name: rs.Fields, Me.Type, obj.Loop.
+	kind := Identifier
+	if !l.afterDot() {
+		if second, ok := compoundKeywords[string(lower)]; ok {
+			if kind, ok := l.scanCompoundKeyword(second); ok {
+				l.add(kind, l.Source[start:l.Pos])
+				return
+			}
+		}
+
+		if k, ok := keywords[string(lower)]; ok {
+			kind = k
+		}
+	}
+	if suffix := l.scanIdentifierSuffix(kind); suffix != NoSuffix {
+		l.emit(Token{Kind: Identifier, Value: word, Suffix: suffix})
+		return
+	}
+
+	l.add(kind, word)
+}
+
+// scanIdentifierSuffix consumes the type suffix of an identifier, if any.
+// Keywords do not take a suffix, with the exception of String$ which is the
+// name of a built-in function.
+func (l *lexer) scanIdentifierSuffix(kind Kind) TypeSuffix {
+	suffix := TypeSuffix(l.peekAt(0))
+	switch suffix {
+	case IntegerSuffix, DoubleSuffix, CurrencySuffix, StringSuffix:
+	case LongSuffix:
+		// &H and &O after a name start a numeric literal, not a suffix.
+		if l.atNumber() {
+			return NoSuffix
+		}
+	case SingleSuffix:
+		// A '!' followed by a name is the bang operator (rs!Field), not a suffix.
+		if isLetter(l.runeAt(1)) || l.peekAt(1) == '[' {
+			return NoSuffix
+		}
+	default:
+		return NoSuffix
+	}
+
+	if kind != Identifier && (kind != StringType || suffix != StringSuffix) {
+		return NoSuffix
+	}
+
+	l.advance()
+	return suffix
+}
+
+func (l *lexer) scanWord() string {
+	start := l.Pos
+	for l.Pos < len(l.Source) && isLetterOrDigitOrUnderscore(l.runeAt(0)) {
+		l.advanceN(utf8.RuneLen(l.runeAt(0)))
 	}
-	for l.Pos < len(l.Source) && isLetterOrDigitOrUnderscore(l.Source[l.Pos]) {
+	return l.Source[start:l.Pos]
+}
+
+// scanCompoundKeyword tries to scan the second word of a compound keyword such
+// as "End If". If the next word does not complete one of the given keywords,
+// the lexer is rewound to the end of the first word.
+func (l *lexer) scanCompoundKeyword(second map[string]Kind) (Kind, bool) {
+	pos, column := l.Pos, l.Column
+	for l.Pos < len(l.Source) && (l.Source[l.Pos] == ' ' || l.Source[l.Pos] == '\t') {
 		l.advance()
 	}
-	l.add(Identifier, l.Source[start:l.Pos])
+	if l.Pos > pos && isLetter(l.runeAt(0)) {
+		var buf [maxKeywordLen]byte
+		if kind, ok := second[string(foldKeyword(&buf, l.scanWord()))]; ok {
+			return kind, true
+		}
+	}
+	l.Pos, l.Column = pos, column
+	return Identifier, false
+}
+
+// atStmtStart reports whether the token being scanned is the first token of
+// a statement, that is, the first token on its line or the first one after a
+// colon.
+func (l *lexer) atStmtStart() bool {
+	if len(l.Tokens) == 0 {
+		return true
+	}
+	kind := l.Tokens[len(l.Tokens)-1].Kind
+	return kind == LineBreak || kind == Colon
 }
 
+// afterDot reports whether the token being scanned directly follows a dot.
+func (l *lexer) afterDot() bool {
+	return len(l.Tokens) > 0 && l.Tokens[len(l.Tokens)-1].Kind == Dot
+}
+
+// tokenizeBracketedIdentifier scans a name escaped with square brackets, such
+// as [Name] or [End]. The text between the brackets is the name, taken as-is,
+// so it can be a keyword or contain characters a plain name cannot.
+func (l *lexer) tokenizeBracketedIdentifier() {
+	l.advance()
+
+	start := l.Pos
+	for l.Pos < len(l.Source) && l.Source[l.Pos] != ']' {
+		if l.Source[l.Pos] == '\n' || l.Source[l.Pos] == '\r' {
+			break
+		}
+		l.advance()
+	}
+
+	name := l.Source[start:l.Pos]
+	if l.peekAt(0) != ']' {
+		l.errorf("unterminated bracketed identifier")
+	} else {
+		l.advance()
+	}
+
+	l.add(Identifier, name)
+}
+
+// tokenizeIllegal consumes a single character the lexer does not recognize,
+// records a diagnostic for it and emits an Illegal token.
+func (l *lexer) tokenizeIllegal() {
+	r, size := utf8.DecodeRuneInString(l.Source[l.Pos:])
+	l.errorf("unexpected character %q", r)
+	l.advanceN(size)
+	l.add(Illegal, l.Source[l.StartPos:l.Pos])
+}
+
+// errorf records a diagnostic at the start of the token being scanned.
+func (l *lexer) errorf(format string, args ...any) {
+	l.Diagnostics = append(l.Diagnostics, Diagnostic{
+		Message: fmt.Sprintf(format, args...),
+		Line:    l.StartLine,
+		Column:  l.StartColumn,
+	})
+}
+
+func (l *lexer) markStart() {
+	l.StartPos, l.StartLine, l.StartColumn = l.Pos, l.Line, l.Column
+}
+
+// advance moves to the next byte. Lines may end in CRLF, LF or a lone CR;
+// the CR of a CRLF pair counts as a column on the line it ends. Columns count
+// characters rather than bytes, so the continuation bytes of a multi-byte
+// character do not move the column.
 func (l *lexer) advance() {
+	c := l.Source[l.Pos]
 	l.Pos++
-	if l.Source[l.Pos-1] == '\n' {
+	if c == '\n' || c == '\r' && l.peekAt(0) != '\n' {
 		l.Line++
 		l.Column = 1
-	} else {
+	} else if !isContinuationByte(c) {
 		l.Column++
 	}
 }
 
+// skipByteOrderMark skips a UTF-8 byte order mark at the start of the source.
+// The mark does not count as a column, so the first token on the line is
+// still at column 1.
+func (l *lexer) skipByteOrderMark() {
+	if !strings.HasPrefix(l.Source, byteOrderMark) {
+		return
+	}
+
+	l.markStart()
+	l.Pos += len(byteOrderMark)
+	l.addTrivia(WhitespaceTrivia)
+}
+
 func (l *lexer) advanceN(n int) {
 	for i := 0; i < n; i++ {
 		l.advance()
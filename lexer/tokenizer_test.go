@@ -0,0 +1,415 @@
+package lexer
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func assertKinds(t *testing.T, tokens []Token, expected ...Kind) {
+	t.Helper()
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %d tokens, got %v", len(expected), tokens)
+	}
+	for i := range expected {
+		if tokens[i].Kind != expected[i] {
+			t.Fatalf("token %d: expected %s, got %s (%v)", i, TokenKindString(expected[i]), TokenKindString(tokens[i].Kind), tokens)
+		}
+	}
+}
+
+func TestTokenizeIllegalCharacters(t *testing.T) {
+	tokens, diagnostics := TokenizeWithDiagnostics("x = 1 ~ 2\ny = ?\n$")
+
+	assertKinds(t, tokens,
+		Identifier, Equal, Number, Illegal, Number, LineBreak,
+		Identifier, Equal, Illegal, LineBreak,
+		Illegal, EOF)
+
+	positions := []struct{ line, column int }{{1, 7}, {2, 5}, {3, 1}}
+	if len(diagnostics) != len(positions) {
+		t.Fatalf("expected %d diagnostics, got %v", len(positions), diagnostics)
+	}
+	for i, pos := range positions {
+		if diagnostics[i].Line != pos.line || diagnostics[i].Column != pos.column {
+			t.Errorf("diagnostic %d: expected %d:%d, got %d:%d", i, pos.line, pos.column, diagnostics[i].Line, diagnostics[i].Column)
+		}
+	}
+}
+
+func TestTokenizeKeywords(t *testing.T) {
+	cases := []struct {
+		name     string
+		source   string
+		expected []Kind
+	}{
+		{"lower case", "dim x as integer", []Kind{Dim, Identifier, As, IntegerType, EOF}},
+		{"upper case compound", "END IF", []Kind{EndIf, EOF}},
+		{"compound with extra whitespace", "Exit  \tFunction", []Kind{ExitFunction, EOF}},
+		{"option explicit", "option explicit", []Kind{OptionExplicit, EOF}},
+		{"identifiers starting with keywords", "Order Total Typeface Android Double_Click", []Kind{Identifier, Identifier, Identifier, Identifier, Identifier, EOF}},
+		{"first word of compound alone", "End\nExit x", []Kind{Identifier, LineBreak, Identifier, Identifier, EOF}},
+		{"first word followed by keyword", "End Sub Sub", []Kind{EndSub, Sub, EOF}},
+		{"property procedures", "Friend Static Property Get\nProperty Let\nProperty  Set\nEnd Property\nExit Property", []Kind{Friend, Static, PropertyGet, LineBreak, PropertyLet, LineBreak, PropertySet, LineBreak, EndProperty, LineBreak, ExitProperty, EOF}},
+		{"property as a name", "Property = 1", []Kind{Identifier, Equal, Number, EOF}},
+		{"rem comment", "rem a comment\nRemark = 1", []Kind{LineBreak, Identifier, Equal, Number, EOF}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assertKinds(t, Tokenize(c.source), c.expected...)
+		})
+	}
+}
+
+func TestTokenizeOperatorKeywords(t *testing.T) {
+	assertKinds(t, Tokenize("a Eqv b Imp c"), Identifier, Eqv, Identifier, Imp, Identifier, EOF)
+	assertKinds(t, Tokenize("s Like \"*.ini\""), Identifier, Like, String, EOF)
+	assertKinds(t, Tokenize("TypeOf obj Is Nothing"), TypeOf, Identifier, Is, Identifier, EOF)
+	assertKinds(t, Tokenize("AddressOf WndProc"), AddressOf, Identifier, EOF)
+}
+
+func TestTokenizeCompoundKeywordValue(t *testing.T) {
+	tokens := Tokenize("  End   If")
+	tok := tokens[0]
+	if tok.Kind != EndIf || tok.Value != "End   If" || tok.Column != 3 {
+		t.Errorf("expected EndIf ('End   If') at column 3, got %s ('%s') at column %d", TokenKindString(tok.Kind), tok.Value, tok.Column)
+	}
+}
+
+func TestTokenizeNumbers(t *testing.T) {
+	cases := []struct {
+		source string
+		value  string
+		radix  int
+		suffix TypeSuffix
+	}{
+		{"42", "42", 10, NoSuffix},
+		{"3.14", "3.14", 10, NoSuffix},
+		{".5", ".5", 10, NoSuffix},
+		{"1E+10", "1E+10", 10, NoSuffix},
+		{"2.5D-3", "2.5D-3", 10, NoSuffix},
+		{"10#", "10", 10, DoubleSuffix},
+		{"7%", "7", 10, IntegerSuffix},
+		{"100000&", "100000", 10, LongSuffix},
+		{"1.5!", "1.5", 10, SingleSuffix},
+		{"9.99@", "9.99", 10, CurrencySuffix},
+		{"&HFF", "FF", 16, NoSuffix},
+		{"&hffff&", "ffff", 16, LongSuffix},
+		{"&O17", "17", 8, NoSuffix},
+	}
+
+	for _, c := range cases {
+		t.Run(c.source, func(t *testing.T) {
+			tokens, diagnostics := TokenizeWithDiagnostics(c.source)
+			if len(diagnostics) > 0 {
+				t.Fatalf("unexpected diagnostics: %v", diagnostics)
+			}
+			assertKinds(t, tokens, Number, EOF)
+			tok := tokens[0]
+			if tok.Value != c.value || tok.Radix != c.radix || tok.Suffix != c.suffix {
+				t.Errorf("expected %s (radix %d, suffix %q), got %s (radix %d, suffix %q)", c.value, c.radix, c.suffix, tok.Value, tok.Radix, tok.Suffix)
+			}
+		})
+	}
+}
+
+func TestTokenizeNumbersInExpressions(t *testing.T) {
+	assertKinds(t, Tokenize("x = a & &HFF & b"), Identifier, Equal, Identifier, Concat, Number, Concat, Identifier, EOF)
+	assertKinds(t, Tokenize("s = a &Hz"), Identifier, Equal, Identifier, Concat, Identifier, EOF)
+	assertKinds(t, Tokenize("x = 1E"), Identifier, Equal, Number, Identifier, EOF)
+	assertKinds(t, Tokenize("x = Player(1).Name"), Identifier, Equal, Identifier, LParen, Number, RParen, Dot, Identifier, EOF)
+
+	_, diagnostics := TokenizeWithDiagnostics("x = 1.5% + &HFF!")
+	if len(diagnostics) != 2 {
+		t.Errorf("expected 2 diagnostics, got %v", diagnostics)
+	}
+}
+
+func TestTokenizeDates(t *testing.T) {
+	tokens := Tokenize("If d > #12/31/1999 11:59 PM# Then")
+	assertKinds(t, tokens, If, Identifier, GreaterThan, DateLiteral, Then, EOF)
+	if tokens[3].Value != "12/31/1999 11:59 PM" {
+		t.Errorf("expected date value '12/31/1999 11:59 PM', got '%s'", tokens[3].Value)
+	}
+
+	assertKinds(t, Tokenize("Print #1, #1/2/2003#"), Identifier, FileNumber, Number, Comma, DateLiteral, EOF)
+	assertKinds(t, Tokenize("Close #FileNum"), Identifier, FileNumber, Identifier, EOF)
+	assertKinds(t, Tokenize("x = 10#"), Identifier, Equal, Number, EOF)
+}
+
+func TestParseDate(t *testing.T) {
+	cases := []struct {
+		source   string
+		expected time.Time
+	}{
+		{"1/2/2003", time.Date(2003, time.January, 2, 0, 0, 0, 0, time.UTC)},
+		{"12/31/1999 11:59 PM", time.Date(1999, time.December, 31, 23, 59, 0, 0, time.UTC)},
+		{"2003-01-02 13:45:10", time.Date(2003, time.January, 2, 13, 45, 10, 0, time.UTC)},
+		{"1/2/29", time.Date(2029, time.January, 2, 0, 0, 0, 0, time.UTC)},
+		{"1/2/30", time.Date(1930, time.January, 2, 0, 0, 0, 0, time.UTC)},
+		{"January 5, 2001", time.Date(2001, time.January, 5, 0, 0, 0, 0, time.UTC)},
+		{"5-jan-2001 8:00 am", time.Date(2001, time.January, 5, 8, 0, 0, 0, time.UTC)},
+		{"14:30", time.Date(1899, time.December, 30, 14, 30, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.source, func(t *testing.T) {
+			actual, err := ParseDate(c.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !actual.Equal(c.expected) {
+				t.Errorf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+
+	if _, err := ParseDate("1, "); err == nil {
+		t.Error("expected an error for an invalid date")
+	}
+}
+
+func TestTokenizeStrings(t *testing.T) {
+	tokens := Tokenize(`s = "He said ""hi""" & ""`)
+	assertKinds(t, tokens, Identifier, Equal, String, Concat, String, EOF)
+	if tokens[2].Value != `He said "hi"` || tokens[2].Raw != `"He said ""hi"""` {
+		t.Errorf("unexpected string token value '%s' (raw '%s')", tokens[2].Value, tokens[2].Raw)
+	}
+	if tokens[4].Value != "" || tokens[4].Raw != `""` {
+		t.Errorf("unexpected empty string token value '%s' (raw '%s')", tokens[4].Value, tokens[4].Raw)
+	}
+
+	tokens, diagnostics := TokenizeWithDiagnostics("s = \"unterminated\nx = 1")
+	assertKinds(t, tokens, Identifier, Equal, String, LineBreak, Identifier, Equal, Number, EOF)
+	if tokens[2].Value != "unterminated" {
+		t.Errorf("expected value 'unterminated', got '%s'", tokens[2].Value)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Line != 1 || diagnostics[0].Column != 5 {
+		t.Errorf("expected a single diagnostic at 1:5, got %v", diagnostics)
+	}
+}
+
+func TestTokenizeIdentifierSuffixes(t *testing.T) {
+	tokens := Tokenize("s$ = Left$(sName$, i%) & Total& & x! & y# & c@")
+	assertKinds(t, tokens,
+		Identifier, Equal, Identifier, LParen, Identifier, Comma, Identifier, RParen,
+		Concat, Identifier, Concat, Identifier, Concat, Identifier, Concat, Identifier, EOF)
+
+	expected := map[int]struct {
+		value  string
+		suffix TypeSuffix
+	}{
+		0:  {"s", StringSuffix},
+		2:  {"Left", StringSuffix},
+		4:  {"sName", StringSuffix},
+		6:  {"i", IntegerSuffix},
+		9:  {"Total", LongSuffix},
+		11: {"x", SingleSuffix},
+		13: {"y", DoubleSuffix},
+		15: {"c", CurrencySuffix},
+	}
+	for i, e := range expected {
+		if tokens[i].Value != e.value || tokens[i].Suffix != e.suffix {
+			t.Errorf("token %d: expected %s with suffix %q, got %s with suffix %q", i, e.value, e.suffix, tokens[i].Value, tokens[i].Suffix)
+		}
+	}
+
+	tokens = Tokenize("s = String$(10, \"-\")")
+	if tokens[2].Kind != Identifier || tokens[2].Value != "String" || tokens[2].Suffix != StringSuffix {
+		t.Errorf("expected String$ to be an identifier, got %v", tokens[2])
+	}
+
+	assertKinds(t, Tokenize("x = a&HFF"), Identifier, Equal, Identifier, Number, EOF)
+	assertKinds(t, Tokenize("x = rs!Name"), Identifier, Equal, Identifier, Illegal, Identifier, EOF)
+}
+
+func TestTokenizeLineContinuation(t *testing.T) {
+	assertKinds(t, Tokenize("x = 1 + _\n    2\ny = 3"), Identifier, Equal, Number, Add, Number, LineBreak, Identifier, Equal, Number, EOF)
+
+	_, diagnostics := TokenizeWithDiagnostics("x = 1 _ + 2")
+	if len(diagnostics) != 1 {
+		t.Errorf("expected a diagnostic for a misplaced line continuation, got %v", diagnostics)
+	}
+}
+
+func TestTokenizeColons(t *testing.T) {
+	assertKinds(t, Tokenize("x = 1: y = 2"), Identifier, Equal, Number, Colon, Identifier, Equal, Number, EOF)
+	assertKinds(t, Tokenize("ErrHandler:\n"), Identifier, Colon, LineBreak, EOF)
+
+	// Rem starts a comment after a colon, like at the start of a line.
+	assertKinds(t, Tokenize("x = 1: Rem set x"), Identifier, Equal, Number, Colon, EOF)
+	assertKinds(t, Tokenize("x = Rem"), Identifier, Equal, Identifier, EOF)
+}
+
+func TestTokenizeMemberNames(t *testing.T) {
+	assertKinds(t, Tokenize("Me.Type = obj.Loop + rs.Fields"), Identifier, Dot, Identifier, Equal, Identifier, Dot, Identifier, Add, Identifier, Dot, Identifier, EOF)
+
+	// "End If" after a dot is the member End followed by the keyword If.
+	assertKinds(t, Tokenize("x.End If"), Identifier, Dot, Identifier, If, EOF)
+}
+
+func TestTokenizeBracketedIdentifiers(t *testing.T) {
+	tokens, diagnostics := TokenizeWithDiagnostics("[End] = [My Field]")
+	assertKinds(t, tokens, Identifier, Equal, Identifier, EOF)
+	if tokens[0].Value != "End" || tokens[0].Raw != "[End]" || tokens[2].Value != "My Field" {
+		t.Errorf("unexpected bracketed identifiers %q and %q", tokens[0].Value, tokens[2].Value)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diagnostics)
+	}
+
+	tokens, diagnostics = TokenizeWithDiagnostics("[Name\nx")
+	assertKinds(t, tokens, Identifier, LineBreak, Identifier, EOF)
+	if len(diagnostics) != 1 {
+		t.Errorf("expected a diagnostic for an unterminated bracketed identifier, got %v", diagnostics)
+	}
+}
+
+func TestTokenizeTrivia(t *testing.T) {
+	source := "Type PlayerRec\n    ' General\n    Name As String * 10 ' the name\n\nRem done\n  x = 1 + _\n      2\nEnd   Type  "
+	tokens, diagnostics := TokenizeWithOptions(source, Options{Trivia: true})
+	if len(diagnostics) > 0 {
+		t.Fatalf("unexpected diagnostics: %v", diagnostics)
+	}
+
+	var sb strings.Builder
+	for _, tok := range tokens {
+		for _, trivia := range tok.LeadingTrivia {
+			sb.WriteString(trivia.Text)
+		}
+		sb.WriteString(tok.Raw)
+		for _, trivia := range tok.TrailingTrivia {
+			sb.WriteString(trivia.Text)
+		}
+	}
+	if sb.String() != source {
+		t.Errorf("round trip failed:\n%q\n%q", source, sb.String())
+	}
+
+	// The section header comment is leading trivia of the line break that
+	// ends its line, after the indentation.
+	header := tokens[3]
+	if header.Kind != LineBreak || len(header.LeadingTrivia) != 2 || header.LeadingTrivia[1] != (Trivia{Kind: CommentTrivia, Text: "' General"}) {
+		t.Errorf("unexpected trivia on %v: %v", header, header.LeadingTrivia)
+	}
+
+	// A comment after a statement is trailing trivia of the last token.
+	ten := tokens[8]
+	if ten.Value != "10" || len(ten.TrailingTrivia) != 2 || ten.TrailingTrivia[1] != (Trivia{Kind: CommentTrivia, Text: "' the name"}) {
+		t.Errorf("unexpected trivia on %v: %v", ten, ten.TrailingTrivia)
+	}
+
+	// A line continuation becomes leading trivia of the token that follows it.
+	two := tokens[16]
+	if two.Value != "2" || len(two.LeadingTrivia) != 2 || two.LeadingTrivia[0] != (Trivia{Kind: LineContinuationTrivia, Text: "_\n"}) {
+		t.Errorf("unexpected trivia on %v: %v", two, two.LeadingTrivia)
+	}
+
+	// Without the option no trivia is recorded.
+	for _, tok := range Tokenize(source) {
+		if tok.LeadingTrivia != nil || tok.TrailingTrivia != nil {
+			t.Fatalf("unexpected trivia on %v", tok)
+		}
+	}
+}
+
+func TestTokenOffsets(t *testing.T) {
+	source := "x = \"a\"\"b\" & Foo$\n  End  If"
+	for _, tok := range Tokenize(source) {
+		if source[tok.Start:tok.End] != tok.Raw {
+			t.Errorf("token %v: source[%d:%d] is %q, expected %q", tok, tok.Start, tok.End, source[tok.Start:tok.End], tok.Raw)
+		}
+	}
+}
+
+func TestLineMap(t *testing.T) {
+	source := "Dim x\n\n  x = 1\n"
+	m := NewLineMap(source)
+	for _, tok := range Tokenize(source) {
+		line, column := m.Position(tok.Start)
+		if line != tok.Line || column != tok.Column {
+			t.Errorf("token %v at %d:%d, line map reports %d:%d", tok, tok.Line, tok.Column, line, column)
+		}
+		if offset := m.Offset(tok.Line, tok.Column); offset != tok.Start {
+			t.Errorf("token %v starts at offset %d, line map reports %d", tok, tok.Start, offset)
+		}
+	}
+}
+
+func TestTokenizeLineEndings(t *testing.T) {
+	for _, eol := range []string{"\r\n", "\n", "\r"} {
+		t.Run(strconv.Quote(eol), func(t *testing.T) {
+			source := "\uFEFFDim x" + eol + eol + "  x = 1 _" + eol + "  + 2 ' done" + eol + "End If"
+			tokens := Tokenize(source)
+			assertKinds(t, tokens,
+				Dim, Identifier, LineBreak,
+				LineBreak,
+				Identifier, Equal, Number, Add, Number, LineBreak,
+				EndIf, EOF)
+
+			positions := []struct{ line, column int }{
+				{1, 1}, {1, 5}, {1, 6},
+				{2, 1},
+				{3, 3}, {3, 5}, {3, 7}, {4, 3}, {4, 5}, {4, 13},
+				{5, 1}, {5, 7},
+			}
+			m := NewLineMap(source)
+			for i, pos := range positions {
+				tok := tokens[i]
+				if tok.Line != pos.line || tok.Column != pos.column {
+					t.Errorf("token %d (%v): expected %d:%d, got %d:%d", i, tok, pos.line, pos.column, tok.Line, tok.Column)
+				}
+				if line, column := m.Position(tok.Start); line != pos.line || column != pos.column {
+					t.Errorf("token %d (%v): line map reports %d:%d, expected %d:%d", i, tok, line, column, pos.line, pos.column)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenizeUnicodeIdentifiers(t *testing.T) {
+	source := "Dim Größe As Long\nnaïve = \"café\" & Größe"
+	tokens, diagnostics := TokenizeWithDiagnostics(source)
+	if len(diagnostics) > 0 {
+		t.Fatalf("unexpected diagnostics: %v", diagnostics)
+	}
+	assertKinds(t, tokens, Dim, Identifier, As, LongType, LineBreak, Identifier, Equal, String, Concat, Identifier, EOF)
+	if tokens[1].Value != "Größe" || tokens[5].Value != "naïve" || tokens[7].Value != "café" {
+		t.Errorf("unexpected values %q, %q, %q", tokens[1].Value, tokens[5].Value, tokens[7].Value)
+	}
+
+	// Columns count characters, not bytes.
+	m := NewLineMap(source)
+	positions := map[int]struct{ line, column int }{2: {1, 11}, 6: {2, 7}, 8: {2, 16}, 9: {2, 18}}
+	for i, pos := range positions {
+		tok := tokens[i]
+		if tok.Line != pos.line || tok.Column != pos.column {
+			t.Errorf("token %d (%v): expected %d:%d, got %d:%d", i, tok, pos.line, pos.column, tok.Line, tok.Column)
+		}
+		if line, column := m.Position(tok.Start); line != pos.line || column != pos.column {
+			t.Errorf("token %d (%v): line map reports %d:%d, expected %d:%d", i, tok, line, column, pos.line, pos.column)
+		}
+		if offset := m.Offset(pos.line, pos.column); offset != tok.Start {
+			t.Errorf("token %d (%v): line map offset %d, expected %d", i, tok, offset, tok.Start)
+		}
+	}
+}
+
+func TestKeywordTablesFitFoldBuffer(t *testing.T) {
+	check := func(word string) {
+		if len(word) > maxKeywordLen || strings.ToLower(word) != word {
+			t.Errorf("keyword %q must be lower case and at most %d characters", word, maxKeywordLen)
+		}
+	}
+	for word := range keywords {
+		check(word)
+	}
+	for first, second := range compoundKeywords {
+		check(first)
+		for word := range second {
+			check(word)
+		}
+	}
+}
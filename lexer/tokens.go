@@ -6,15 +6,17 @@ type Kind int
 
 const (
 	EOF Kind = iota
+	Illegal
 	LineBreak
-	LineContinuation
 	Identifier
 	Number
 	String
+	DateLiteral
 	LParen
 	RParen
 	Concat
 	Comma
+	Colon
 
 	// Operators
 	Add
@@ -34,8 +36,12 @@ const (
 	Or
 	Not
 	Xor
-	// TODO: Eqv
-	// TODO: Imp
+	Eqv
+	Imp
+	Like
+	Is
+	TypeOf
+	AddressOf
 
 	// Keywords
 	Dim
@@ -55,12 +61,22 @@ const (
 	Function
 	EndFunction
 	ExitFunction
+	PropertyGet
+	PropertyLet
+	PropertySet
+	EndProperty
+	ExitProperty
+	Friend
+	Static
 	ByVal
 	ByRef
 	Call
 	Lib
 	Alias
 	DoEvents
+	Set
+	Let
+	New
 
 	// Control Flow
 	If
@@ -79,6 +95,7 @@ const (
 	Wend
 	Do
 	Loop
+	ExitDo
 	Until
 	GoTo
 	With
@@ -97,8 +114,15 @@ const (
 	Dot
 	FileNumber
 
+	// Conditional compilation
+	HashIf
+	HashElseIf
+	HashElse
+	HashEndIf
+	HashConst
+
 	// TODO: Event + RaiseEvent keywords
-	// TODO: AddressOf Lib Alias, Array LBound, UBound
+	// TODO: Array LBound, UBound
 	// TODO: Recordset, OpenDatabase, CloseDatabase, Field, Fields
 	// TODO: File handling: Open, Close, Get, Put, Input, Print, Write, LineInput, EOF, FreeFile, Seek, FileAttr, FileCopy, Kill, Lock, Unlock
 )
@@ -107,16 +131,18 @@ func TokenKindString(kind Kind) string {
 	switch kind {
 	case EOF:
 		return "EOF"
+	case Illegal:
+		return "Illegal"
 	case LineBreak:
 		return "LineBreak"
-	case LineContinuation:
-		return "LineContinuation"
 	case Identifier:
 		return "Identifier"
 	case Number:
 		return "Number"
 	case String:
 		return "String"
+	case DateLiteral:
+		return "DateLiteral"
 	case LParen:
 		return "LParen"
 	case RParen:
@@ -125,6 +151,8 @@ func TokenKindString(kind Kind) string {
 		return "Concat"
 	case Comma:
 		return "Comma"
+	case Colon:
+		return "Colon"
 	case Add:
 		return "Add"
 	case Subtract:
@@ -159,6 +187,18 @@ func TokenKindString(kind Kind) string {
 		return "Not"
 	case Xor:
 		return "Xor"
+	case Eqv:
+		return "Eqv"
+	case Imp:
+		return "Imp"
+	case Like:
+		return "Like"
+	case Is:
+		return "Is"
+	case TypeOf:
+		return "TypeOf"
+	case AddressOf:
+		return "AddressOf"
 	case Dim:
 		return "Dim"
 	case As:
@@ -193,6 +233,22 @@ func TokenKindString(kind Kind) string {
 		return "EndFunction"
 	case ExitFunction:
 		return "ExitFunction"
+	case PropertyGet:
+		return "PropertyGet"
+	case PropertyLet:
+		return "PropertyLet"
+	case PropertySet:
+		return "PropertySet"
+	case EndProperty:
+		return "EndProperty"
+	case ExitProperty:
+		return "ExitProperty"
+	case Friend:
+		return "Friend"
+	case Static:
+		return "Static"
+	case ExitDo:
+		return "ExitDo"
 	case ByVal:
 		return "ByVal"
 	case ByRef:
@@ -205,6 +261,12 @@ func TokenKindString(kind Kind) string {
 		return "Alias"
 	case DoEvents:
 		return "DoEvents"
+	case Set:
+		return "Set"
+	case Let:
+		return "Let"
+	case New:
+		return "New"
 	case If:
 		return "If"
 	case Then:
@@ -263,16 +325,66 @@ func TokenKindString(kind Kind) string {
 		return "Dot"
 	case FileNumber:
 		return "FileNumber"
+	case HashIf:
+		return "#If"
+	case HashElseIf:
+		return "#ElseIf"
+	case HashElse:
+		return "#Else"
+	case HashEndIf:
+		return "#EndIf"
+	case HashConst:
+		return "#Const"
 	default:
 		return "Unknown"
 	}
 }
 
+// TypeSuffix is a type-declaration character that may directly follow a
+// literal or an identifier, such as the & in 10& or the $ in Name$.
+type TypeSuffix byte
+
+const (
+	NoSuffix       TypeSuffix = 0
+	IntegerSuffix  TypeSuffix = '%'
+	LongSuffix     TypeSuffix = '&'
+	SingleSuffix   TypeSuffix = '!'
+	DoubleSuffix   TypeSuffix = '#'
+	CurrencySuffix TypeSuffix = '@'
+	StringSuffix   TypeSuffix = '$'
+)
+
 type Token struct {
 	Value  string
 	Kind   Kind
 	Line   int
 	Column int
+
+	// Start and End are the byte offsets of the token in the source. Start is
+	// inclusive and End is exclusive.
+	Start int
+	End   int
+
+	// Raw is the source text of the token exactly as written. For most tokens
+	// it is the same as Value, but a String token for instance has its quotes
+	// and escapes in Raw and only the unescaped text in Value.
+	Raw string
+
+	// LeadingTrivia and TrailingTrivia hold the whitespace, comments and line
+	// continuations around the token. They are only set when the lexer runs
+	// with Options.Trivia.
+	LeadingTrivia  []Trivia
+	TrailingTrivia []Trivia
+
+	// Radix is the base of a Number token: 10, 16 (&H) or 8 (&O). The Value
+	// of a Number token holds only its digits, without the radix prefix or
+	// the type suffix.
+	Radix int
+
+	// Suffix is the type suffix of a Number or Identifier token. The Value of
+	// an Identifier token does not include the suffix, so Name$ and Name both
+	// have the value "Name".
+	Suffix TypeSuffix
 }
 
 func (t Token) isOneOf(kinds ...Kind) bool {
@@ -294,7 +406,8 @@ func (t Token) IsOperator() bool {
 		GreaterThanOrEqual,
 		LessThan,
 		LessThanOrEqual,
-		And, Or, Not, Xor)
+		Like, Is,
+		And, Or, Not, Xor, Eqv, Imp)
 }
 
 func (t Token) IsArithmeticOperator() bool {
@@ -302,11 +415,11 @@ func (t Token) IsArithmeticOperator() bool {
 }
 
 func (t Token) IsComparisonOperator() bool {
-	return t.isOneOf(Equal, NotEqual, GreaterThan, GreaterThanOrEqual, LessThan, LessThanOrEqual)
+	return t.isOneOf(Equal, NotEqual, GreaterThan, GreaterThanOrEqual, LessThan, LessThanOrEqual, Like, Is)
 }
 
 func (t Token) IsLogicalOperator() bool {
-	return t.isOneOf(And, Or, Not, Xor)
+	return t.isOneOf(And, Or, Not, Xor, Eqv, Imp)
 }
 
 func (t Token) IsControlFlow() bool {
@@ -317,16 +430,10 @@ func (t Token) IsControlFlow() bool {
 		Do, Loop, Until, GoTo, With, EndWith)
 }
 
+// IsKeyword reports whether the token is a keyword. The answer comes from the
+// keyword tables the lexer uses, so it covers every keyword it recognizes.
 func (t Token) IsKeyword() bool {
-	return t.isOneOf(
-		Dim, As, Const, Private, Public,
-		ReDim, OptionExplicit, Type, EndType,
-		Enum, EndEnum, Declare, Sub, Function,
-		ByVal, ByRef, Call,
-		If, Then, Else, ElseIf, EndIf,
-		Select, Case, EndSelect,
-		For, To, Step, While, Wend,
-		Do, Loop, Until, GoTo, With, EndWith)
+	return keywordKinds[t.Kind]
 }
 
 func (t Token) IsDataType() bool {
@@ -341,7 +448,7 @@ func (t Token) IsDataType() bool {
 }
 
 func (t Token) String() string {
-	if t.isOneOf(Identifier, String, Number) {
+	if t.isOneOf(Illegal, Identifier, String, Number, DateLiteral) {
 		return fmt.Sprintf("%s (%s)", TokenKindString(t.Kind), t.Value)
 	} else {
 		return TokenKindString(t.Kind)
@@ -0,0 +1,41 @@
+package lexer
+
+// TriviaKind identifies the kind of source text that is not part of any
+// token.
+type TriviaKind int
+
+const (
+	WhitespaceTrivia TriviaKind = iota
+	CommentTrivia
+	LineContinuationTrivia
+)
+
+func TriviaKindString(kind TriviaKind) string {
+	switch kind {
+	case WhitespaceTrivia:
+		return "Whitespace"
+	case CommentTrivia:
+		return "Comment"
+	case LineContinuationTrivia:
+		return "LineContinuation"
+	default:
+		return "Unknown"
+	}
+}
+
+// Trivia is a piece of source text that is not part of any token, such as
+// indentation, a comment or a line continuation. Trivia is only recorded when
+// the lexer runs with Options.Trivia set.
+//
+// Trivia on the same line after a token, up to but not including the line
+// break, is attached to that token as trailing trivia. All other trivia is
+// attached to the next token as leading trivia. Blank lines are not trivia;
+// each one is still a LineBreak token, carrying the indentation of the line as
+// leading trivia.
+//
+// Concatenating the leading trivia, the Raw text and the trailing trivia of
+// every token reproduces the original source exactly.
+type Trivia struct {
+	Kind TriviaKind
+	Text string
+}
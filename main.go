@@ -1,20 +1,20 @@
 package main
 
 import (
-	"os"
-
 	"github.com/guthius/vb6/lexer"
 	"github.com/guthius/vb6/parser"
+	"github.com/guthius/vb6/source"
 	"github.com/sanity-io/litter"
 )
 
 func main() {
-	// bytes, _ := os.ReadFile("parser/testcases/02_declarations.bas")
-	bytes, _ := os.ReadFile("reference/modTypes.bas")
-	source := string(bytes)
+	// file, err := source.ReadFile("parser/testcases/02_declarations.bas", source.Options{})
+	file, err := source.ReadFile("reference/modTypes.bas", source.Options{})
+	if err != nil {
+		panic(err)
+	}
 
-	tokens := lexer.Tokenize(source)
-	ast := parser.Parse(tokens)
+	ast := parser.ParseScanner(lexer.NewScanner(file.Text, lexer.Options{}))
 
 	litter.Dump(ast)
 }
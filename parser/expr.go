@@ -2,7 +2,9 @@ package parser
 
 import (
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 
 	"github.com/guthius/vb6/ast"
 	"github.com/guthius/vb6/lexer"
@@ -13,19 +15,17 @@ func parseExpr(p *parser, bp bindingPower) ast.Expr {
 
 	nfn, ok := tables.nud[kind]
 	if !ok {
-		for _, k := range allowedIdentifiers {
-			if kind == k {
-				return parseSymbolExpr(p)
-			}
-		}
 		panic(p.unexpected())
 	}
 
-	left := nfn(p)
-	for tables.bp[p.peek()] > bp {
-		kind = p.peek()
+	return parseInfixExpr(p, nfn(p), bp)
+}
 
-		lfn, ok := tables.led[kind]
+// parseInfixExpr continues an expression whose first operand has already
+// been parsed, for as long as the operators bind more tightly than bp.
+func parseInfixExpr(p *parser, left ast.Expr, bp bindingPower) ast.Expr {
+	for tables.bp[p.peek()] > bp && !p.isDetachedDot() {
+		lfn, ok := tables.led[p.peek()]
 		if !ok {
 			panic(p.unexpected())
 		}
@@ -40,16 +40,19 @@ func parsePrimaryExpr(p *parser) ast.Expr {
 	kind := p.peek()
 	switch kind {
 	case lexer.Number:
-		t := p.next()
-		v, err := strconv.ParseFloat(t.Value, 64)
-		if err != nil {
-			panic(fmt.Errorf("invalid number %s", t.Value))
-		}
-		return ast.NumberExpr{Value: v}
+		return parseNumberExpr(p)
 
 	case lexer.String:
 		t := p.next()
-		return ast.StringExpr{Value: t.Value}
+		return ast.StringExpr{Span: tokenSpan(t), Value: t.Value}
+
+	case lexer.DateLiteral:
+		t := p.next()
+		v, err := lexer.ParseDate(t.Value)
+		if err != nil {
+			panic(err)
+		}
+		return ast.DateExpr{Span: tokenSpan(t), Value: v}
 
 	case lexer.Identifier:
 		return parseSymbolExpr(p)
@@ -59,16 +62,87 @@ func parsePrimaryExpr(p *parser) ast.Expr {
 	}
 }
 
+func parseNumberExpr(p *parser) ast.Expr {
+	t := p.next()
+	if t.Radix == 16 || t.Radix == 8 {
+		return parseRadixNumberExpr(t)
+	}
+
+	v, err := strconv.ParseFloat(strings.NewReplacer("d", "e", "D", "e").Replace(t.Value), 64)
+	if err != nil {
+		panic(fmt.Errorf("invalid number %s", t.Value))
+	}
+
+	// A suffix that asks for an integer type must fit it. The sign is a
+	// separate operator, so 32768% overflows even in -32768%.
+	switch {
+	case t.Suffix == lexer.IntegerSuffix && v > math.MaxInt16:
+		panic(fmt.Errorf("overflow in Integer literal %s", t.Value))
+	case t.Suffix == lexer.LongSuffix && v > math.MaxInt32:
+		panic(fmt.Errorf("overflow in Long literal %s", t.Value))
+	}
+
+	if dataType, ok := suffixTypeMap[t.Suffix]; ok {
+		return ast.NumberExpr{Span: tokenSpan(t), Value: v, Type: dataType}
+	}
+
+	// Without a suffix a literal gets the smallest type that can hold it.
+	dataType := ast.DtDouble
+	if !strings.ContainsAny(t.Value, ".eEdD") {
+		switch {
+		case v >= math.MinInt16 && v <= math.MaxInt16:
+			dataType = ast.DtInteger
+		case v >= math.MinInt32 && v <= math.MaxInt32:
+			dataType = ast.DtLong
+		}
+	}
+
+	return ast.NumberExpr{Span: tokenSpan(t), Value: v, Type: dataType}
+}
+
+// parseRadixNumberExpr converts a hexadecimal or octal literal. These are
+// two's complement bit patterns, so &HFFFF is -1 as an Integer while &HFFFF&
+// is 65535 as a Long.
+func parseRadixNumberExpr(t lexer.Token) ast.Expr {
+	v, err := strconv.ParseUint(t.Value, t.Radix, 32)
+	if err != nil {
+		panic(fmt.Errorf("invalid number %s", t.Value))
+	}
+
+	if t.Suffix != lexer.LongSuffix && v <= math.MaxUint16 {
+		return ast.NumberExpr{Span: tokenSpan(t), Value: float64(int16(v)), Type: ast.DtInteger}
+	}
+	if t.Suffix == lexer.IntegerSuffix {
+		panic(fmt.Errorf("overflow in Integer literal %s", t.Value))
+	}
+
+	return ast.NumberExpr{Span: tokenSpan(t), Value: float64(int32(v)), Type: ast.DtLong}
+}
+
 func parseSymbolExpr(p *parser) ast.Expr {
+	start := p.pos()
 	identifier := p.expectIdentifier().Value
-	if p.peek() == lexer.LParen {
-		return parseCallExpr(p, identifier)
+	return ast.SymbolExpr{Span: p.spanFrom(start), Name: identifier}
+}
+
+// parseImplicitMemberExpr parses a member access that starts with a dot and
+// refers to the object of the enclosing With block.
+func parseImplicitMemberExpr(p *parser) ast.Expr {
+	if p.WithDepth == 0 {
+		panic(p.unexpected())
 	}
 
-	return ast.SymbolExpr{Name: identifier}
+	start := p.pos()
+	p.expect(lexer.Dot)
+	member := p.expectIdentifier().Value
+
+	return ast.ImplicitMemberExpr{Span: p.spanFrom(start), Member: member}
 }
 
-func parseCallExpr(p *parser, identifier string) ast.Expr {
+// parseIndexOrCallExpr parses the parenthesized arguments after an
+// expression. Without knowing the declarations, Player(Index) may be an
+// array element or a function call, so both are parsed the same way.
+func parseIndexOrCallExpr(p *parser, callee ast.Expr, bp bindingPower) ast.Expr {
 	p.expect(lexer.LParen)
 
 	args := []ast.Expr{}
@@ -82,9 +156,41 @@ func parseCallExpr(p *parser, identifier string) ast.Expr {
 
 	p.expect(lexer.RParen)
 
-	return ast.CallExpr{
-		Identifier: identifier,
-		Args:       args,
+	return ast.IndexOrCallExpr{
+		Span:   p.spanFrom(callee.Range().Start),
+		Callee: callee,
+		Args:   args,
+	}
+}
+
+func parseMemberExpr(p *parser, object ast.Expr, bp bindingPower) ast.Expr {
+	p.expect(lexer.Dot)
+	member := p.expectIdentifier().Value
+
+	return ast.MemberExpr{
+		Span:   p.spanFrom(object.Range().Start),
+		Object: object,
+		Member: member,
+	}
+}
+
+// parseUnaryExpr parses Not, negation and unary plus. Not applies to a whole
+// comparison, so Not a = b is Not (a = b). Negation only takes a single
+// operand, which may be raised to a power: -a * b is (-a) * b, but -a ^ 2 is
+// -(a ^ 2).
+func parseUnaryExpr(p *parser) ast.Expr {
+	operator := p.next()
+
+	bp := unary
+	if operator.Kind == lexer.Not {
+		bp = negation
+	}
+	operand := parseExpr(p, bp)
+
+	return ast.UnaryExpr{
+		Span:     ast.Span{Start: operator.Start, End: operand.Range().End},
+		Operator: operator,
+		Operand:  operand,
 	}
 }
 
@@ -93,6 +199,7 @@ func parseBinaryExpr(p *parser, left ast.Expr, bp bindingPower) ast.Expr {
 	right := parseExpr(p, bp)
 
 	return ast.BinaryExpr{
+		Span:     ast.Span{Start: left.Range().Start, End: right.Range().End},
 		Left:     left,
 		Operator: operator,
 		Right:    right,
@@ -109,11 +216,32 @@ var dataTypeMap = map[lexer.Kind]ast.DataType{
 	lexer.StringType:  ast.DtString,
 }
 
+// typeNameMap holds the built-in types that are not keywords, keyed by their
+// lower case name.
+var typeNameMap = map[string]ast.DataType{
+	"currency": ast.DtCurrency,
+	"variant":  ast.DtVariant,
+}
+
+var suffixTypeMap = map[lexer.TypeSuffix]ast.DataType{
+	lexer.IntegerSuffix:  ast.DtInteger,
+	lexer.LongSuffix:     ast.DtLong,
+	lexer.SingleSuffix:   ast.DtSingle,
+	lexer.DoubleSuffix:   ast.DtDouble,
+	lexer.CurrencySuffix: ast.DtCurrency,
+	lexer.StringSuffix:   ast.DtString,
+}
+
 func parseTypeExpr(p *parser) ast.TypeExpr {
+	start := p.pos()
 	cur := p.next()
 	if !cur.IsDataType() {
 		if cur.Kind == lexer.Identifier {
+			if dataType, ok := typeNameMap[strings.ToLower(cur.Value)]; ok {
+				return ast.TypeExpr{Span: p.spanFrom(start), Type: dataType}
+			}
 			return ast.TypeExpr{
+				Span:       p.spanFrom(start),
 				Type:       ast.DtUserDefined,
 				TypeName:   cur.Value,
 				IsFixedLen: false,
@@ -133,6 +261,7 @@ func parseTypeExpr(p *parser) ast.TypeExpr {
 			p.next()
 			len := parseExpr(p, assignment)
 			return ast.TypeExpr{
+				Span:       p.spanFrom(start),
 				Type:       dataType,
 				IsFixedLen: true,
 				Len:        len,
@@ -141,15 +270,91 @@ func parseTypeExpr(p *parser) ast.TypeExpr {
 	}
 
 	return ast.TypeExpr{
+		Span:       p.spanFrom(start),
 		Type:       dataType,
 		IsFixedLen: false,
 		Len:        nil,
 	}
 }
 
+// parseDeclTypeExpr parses the type of a declared name, which is either given
+// by an "As" clause or by the type suffix of the name (Dim s$ is the same as
+// Dim s As String). A name that has neither is a Variant.
+func parseDeclTypeExpr(p *parser, name lexer.Token) ast.TypeExpr {
+	if p.peek() == lexer.As {
+		if name.Suffix != lexer.NoSuffix {
+			panic(fmt.Sprintf("type suffix '%c' on %s conflicts with As clause at line %d, column %d",
+				name.Suffix, name.Value, name.Line, name.Column))
+		}
+		p.next()
+		return parseTypeExpr(p)
+	}
+
+	// An implicit type has no source of its own; it points at the name.
+	if dataType, ok := suffixTypeMap[name.Suffix]; ok {
+		return ast.TypeExpr{Span: tokenSpan(name), Type: dataType}
+	}
+
+	return ast.TypeExpr{Span: tokenSpan(name), Type: ast.DtVariant}
+}
+
+func tokenSpan(t lexer.Token) ast.Span {
+	return ast.Span{Start: t.Start, End: t.End}
+}
+
+// parseTypeOfExpr parses TypeOf object Is type. The object is parsed at
+// relational binding power, so that it ends at the Is.
+func parseTypeOfExpr(p *parser) ast.Expr {
+	start := p.pos()
+	p.expect(lexer.TypeOf)
+	object := parseExpr(p, relational)
+	p.expect(lexer.Is)
+	typeName := parseQualifiedName(p)
+
+	return ast.TypeOfExpr{
+		Span:     p.spanFrom(start),
+		Object:   object,
+		TypeName: typeName,
+	}
+}
+
+func parseNewExpr(p *parser) ast.Expr {
+	start := p.pos()
+	p.expect(lexer.New)
+	typeName := parseQualifiedName(p)
+
+	return ast.NewExpr{
+		Span:     p.spanFrom(start),
+		TypeName: typeName,
+	}
+}
+
+func parseAddressOfExpr(p *parser) ast.Expr {
+	start := p.pos()
+	p.expect(lexer.AddressOf)
+	identifier := parseQualifiedName(p)
+
+	return ast.AddressOfExpr{
+		Span:       p.spanFrom(start),
+		Identifier: identifier,
+	}
+}
+
+// parseQualifiedName parses a name that may be qualified by the name of a
+// library or module, such as VB.TextBox, and returns it as written.
+func parseQualifiedName(p *parser) string {
+	name := p.expect(lexer.Identifier).Value
+	for p.peek() == lexer.Dot {
+		p.next()
+		name += "." + p.expect(lexer.Identifier).Value
+	}
+	return name
+}
+
 func parseGroupExpr(p *parser) ast.Expr {
+	start := p.pos()
 	p.expect(lexer.LParen)
-	expr := parseExpr(p, defaultBindingPower)
+	inner := parseExpr(p, defaultBindingPower)
 	p.expect(lexer.RParen)
-	return expr
+	return ast.GroupExpr{Span: p.spanFrom(start), Inner: inner}
 }
@@ -7,28 +7,32 @@ import (
 
 type bindingPower int
 
+// The binding powers follow the VB6 operator precedence, from loosest to
+// tightest. All binary operators are left-associative, including ^, so
+// 2 ^ 3 ^ 2 is (2 ^ 3) ^ 2.
 const (
 	defaultBindingPower bindingPower = iota
 	comma
 	assignment
-	logical
-	relational
-	additive
-	multiplicative
-	unary
+	implication    // Imp
+	equivalence    // Eqv
+	exclusion      // Xor
+	disjunction    // Or
+	conjunction    // And
+	negation       // Not
+	relational     // = <> < > <= >= Like Is
+	concatenation  // &
+	additive       // + -
+	modulus        // Mod
+	integerDivide  // \
+	multiplicative // * /
+	unary          // - +
+	exponent       // ^
 	call
 	member
 	primary
 )
 
-// allowedIdentifiers is a list of all the tokens that can be used as identifiers.
-var allowedIdentifiers = []lexer.Kind{
-	lexer.Identifier,
-	lexer.Public,
-	lexer.Private,
-	lexer.Type,
-}
-
 type stmtHandler func(*parser) ast.Stmt
 type nudHandler func(*parser) ast.Expr
 type ledHandler func(*parser, ast.Expr, bindingPower) ast.Expr
@@ -48,8 +52,11 @@ func init() {
 	tables.bp = make(map[lexer.Kind]bindingPower)
 
 	// Logical operators
-	led(lexer.And, logical, parseBinaryExpr)
-	led(lexer.Or, logical, parseBinaryExpr)
+	led(lexer.Imp, implication, parseBinaryExpr)
+	led(lexer.Eqv, equivalence, parseBinaryExpr)
+	led(lexer.Xor, exclusion, parseBinaryExpr)
+	led(lexer.Or, disjunction, parseBinaryExpr)
+	led(lexer.And, conjunction, parseBinaryExpr)
 
 	// Relational operators
 	led(lexer.Equal, relational, parseBinaryExpr)
@@ -58,41 +65,69 @@ func init() {
 	led(lexer.GreaterThanOrEqual, relational, parseBinaryExpr)
 	led(lexer.LessThan, relational, parseBinaryExpr)
 	led(lexer.LessThanOrEqual, relational, parseBinaryExpr)
+	led(lexer.Like, relational, parseBinaryExpr)
+	led(lexer.Is, relational, parseBinaryExpr)
 
-	// Additive operators
+	// Arithmetic operators
+	led(lexer.Concat, concatenation, parseBinaryExpr)
 	led(lexer.Add, additive, parseBinaryExpr)
 	led(lexer.Subtract, additive, parseBinaryExpr)
-	led(lexer.Concat, additive, parseBinaryExpr)
-
-	// Multiplicative operators
+	led(lexer.Modulus, modulus, parseBinaryExpr)
+	led(lexer.DivideInt, integerDivide, parseBinaryExpr)
 	led(lexer.Multiply, multiplicative, parseBinaryExpr)
 	led(lexer.Divide, multiplicative, parseBinaryExpr)
-	led(lexer.DivideInt, multiplicative, parseBinaryExpr)
-	led(lexer.Modulus, multiplicative, parseBinaryExpr)
+	led(lexer.Exponent, exponent, parseBinaryExpr)
+
+	// Unary operators
+	prefix(lexer.Not, parseUnaryExpr)
+	prefix(lexer.Subtract, parseUnaryExpr)
+	prefix(lexer.Add, parseUnaryExpr)
 
-	// Member
-	led(lexer.Dot, member, parseBinaryExpr)
+	// Member access, indexing and calls
+	led(lexer.Dot, member, parseMemberExpr)
+	prefix(lexer.Dot, parseImplicitMemberExpr)
+	led(lexer.LParen, call, parseIndexOrCallExpr)
 
 	// Literals and identifiers
 	nud(lexer.Number, primary, parsePrimaryExpr)
 	nud(lexer.String, primary, parsePrimaryExpr)
+	nud(lexer.DateLiteral, primary, parsePrimaryExpr)
 	nud(lexer.Identifier, primary, parsePrimaryExpr)
-	nud(lexer.LParen, primary, parseGroupExpr)
+	prefix(lexer.LParen, parseGroupExpr)
+	nud(lexer.TypeOf, primary, parseTypeOfExpr)
+	nud(lexer.AddressOf, primary, parseAddressOfExpr)
+	nud(lexer.New, primary, parseNewExpr)
 
 	// Statements
 	stmt(lexer.Public, parseDeclStmt)
 	stmt(lexer.Private, parseDeclStmt)
 	stmt(lexer.Const, parsePrivateConstDeclStmt)
 	stmt(lexer.Type, parseTypeStmt)
+	stmt(lexer.Enum, parsePublicEnumDeclStmt)
 	stmt(lexer.Call, parseCallStmt)
 	stmt(lexer.Declare, parseDeclareStmt)
-	stmt(lexer.Function, parseFunctionStmt)
+	stmt(lexer.Function, parsePublicProcedureStmt)
+	stmt(lexer.Sub, parsePublicProcedureStmt)
+	stmt(lexer.PropertyGet, parsePublicProcedureStmt)
+	stmt(lexer.PropertyLet, parsePublicProcedureStmt)
+	stmt(lexer.PropertySet, parsePublicProcedureStmt)
+	stmt(lexer.Static, parsePublicProcedureStmt)
+	stmt(lexer.Friend, parseFriendProcedureStmt)
 	stmt(lexer.Dim, parseDimStmt)
 	stmt(lexer.If, parseIfStmt)
 	stmt(lexer.ExitFunction, parseExitFunctionStmt)
+	stmt(lexer.ExitProperty, parseExitPropertyStmt)
+	stmt(lexer.Do, parseDoStmt)
+	stmt(lexer.While, parseWhileStmt)
+	stmt(lexer.ExitDo, parseExitDoStmt)
 	stmt(lexer.For, parseForStmt)
-	stmt(lexer.Sub, parseSubStmt)
 	stmt(lexer.OptionExplicit, parseOptionExplicitStmt)
+	stmt(lexer.Select, parseSelectStmt)
+	stmt(lexer.With, parseWithStmt)
+	stmt(lexer.Set, parseSetStmt)
+	stmt(lexer.Let, parseLetStmt)
+	stmt(lexer.HashIf, parseConditionalCompilationStmt)
+	stmt(lexer.HashConst, parseConditionalConstStmt)
 }
 
 func stmt(king lexer.Kind, handler stmtHandler) {
@@ -104,6 +139,12 @@ func nud(king lexer.Kind, bp bindingPower, handler nudHandler) {
 	tables.bp[king] = bp
 }
 
+// prefix registers a nud handler without a binding power, for tokens such as
+// '-' that are also binary operators and already have one.
+func prefix(king lexer.Kind, handler nudHandler) {
+	tables.nud[king] = handler
+}
+
 func led(king lexer.Kind, bp bindingPower, handler ledHandler) {
 	tables.led[king] = handler
 	tables.bp[king] = bp
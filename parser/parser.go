@@ -8,60 +8,155 @@ import (
 )
 
 type parser struct {
-	Tokens []lexer.Token
-	Pos    int
+	// Source returns the next token. Once the tokens run out it must keep
+	// returning an EOF token.
+	Source func() lexer.Token
+
+	// Lookahead holds the tokens read from Source but not consumed yet.
+	Lookahead []lexer.Token
+
+	// PrevEnd is the end offset of the last consumed token that was not a
+	// line break or colon, which is where the span of a statement ends.
+	PrevEnd int
+
+	// PrevLine is the line of that token.
+	PrevLine int
+
+	// LineStart is set while the next token is the first one on its line.
+	LineStart bool
+
+	// WithDepth is the number of With blocks around the current position.
+	WithDepth int
 }
 
-func newParser(tokens []lexer.Token) *parser {
+func newParser(source func() lexer.Token) *parser {
 	return &parser{
-		Tokens: tokens,
-		Pos:    0,
+		Source:    source,
+		Lookahead: make([]lexer.Token, 0, 2),
+		LineStart: true,
 	}
 }
 
+// Parse parses a complete list of tokens, as returned by lexer.Tokenize.
 func Parse(tokens []lexer.Token) ast.BlockStmt {
-	return parseBlockStmt(newParser(tokens), lexer.EOF)
+	return parse(sliceSource(tokens))
+}
+
+// sliceSource returns a token source that hands out the given tokens, and
+// then an EOF token after the last one.
+func sliceSource(tokens []lexer.Token) func() lexer.Token {
+	pos := 0
+	eof := lexer.Token{Kind: lexer.EOF}
+	if len(tokens) > 0 {
+		eof.Start = tokens[len(tokens)-1].End
+		eof.End = eof.Start
+	}
+
+	return func() lexer.Token {
+		if pos < len(tokens) {
+			pos++
+			return tokens[pos-1]
+		}
+		return eof
+	}
+}
+
+// ParseScanner parses the tokens produced by the scanner, pulling them from
+// it as they are needed instead of tokenizing the whole source first.
+func ParseScanner(s *lexer.Scanner) ast.BlockStmt {
+	return parse(s.Next)
+}
+
+func parse(source func() lexer.Token) ast.BlockStmt {
+	return parseBlockStmt(newParser(source), lexer.EOF)
+}
+
+// current returns the next token without consuming it.
+func (p *parser) current() lexer.Token {
+	return p.lookahead(0)
+}
+
+// lookahead returns the token n positions ahead of the next one without
+// consuming anything. lookahead(0) is the next token.
+func (p *parser) lookahead(n int) lexer.Token {
+	for len(p.Lookahead) <= n {
+		p.Lookahead = append(p.Lookahead, p.Source())
+	}
+	return p.Lookahead[n]
 }
 
 func (p *parser) peek() lexer.Kind {
-	return p.Tokens[p.Pos].Kind
+	return p.current().Kind
 }
 
 func (p *parser) next() lexer.Token {
-	p.Pos++
-	return p.Tokens[p.Pos-1]
+	tok := p.current()
+	p.Lookahead = p.Lookahead[:copy(p.Lookahead, p.Lookahead[1:])]
+	switch tok.Kind {
+	case lexer.LineBreak, lexer.Colon, lexer.EOF:
+	default:
+		p.PrevEnd = tok.End
+		p.PrevLine = tok.Line
+	}
+	p.LineStart = tok.Kind == lexer.LineBreak
+	return tok
+}
+
+// isDetachedDot reports whether the next token is a dot that is separated
+// from the token before it by whitespace. Such a dot starts an implicit
+// member access, as in Debug.Print .Name, rather than a member of the
+// expression before it. A dot at the start of a continued line still
+// continues the expression.
+func (p *parser) isDetachedDot() bool {
+	tok := p.current()
+	return tok.Kind == lexer.Dot && tok.Start != p.PrevEnd && tok.Line == p.PrevLine
+}
+
+// pos returns the offset at which the next token starts. It marks the start
+// of the node that is about to be parsed, to be passed to spanFrom.
+func (p *parser) pos() int {
+	return p.current().Start
+}
+
+// spanFrom returns the span from the offset start up to the end of the last
+// consumed token. A line break or colon that ends a statement is not part of
+// its span.
+func (p *parser) spanFrom(start int) ast.Span {
+	if p.PrevEnd < start {
+		return ast.Span{Start: start, End: start}
+	}
+	return ast.Span{Start: start, End: p.PrevEnd}
 }
 
 func (p *parser) isEof() bool {
-	return p.Pos >= len(p.Tokens) || p.peek() == lexer.EOF
+	return p.peek() == lexer.EOF
 }
 
 func (p *parser) expect(kind lexer.Kind) lexer.Token {
 	if p.peek() == kind {
 		return p.next()
 	}
-	panic(expectedToken(p.Tokens[p.Pos], kind))
+	panic(expectedToken(p.current(), kind))
 }
 
+// expectIdentifier consumes a name. Where the grammar calls for a name, as in
+// the field declarations of a Type, a keyword is accepted as one.
 func (p *parser) expectIdentifier() lexer.Token {
-	kind := p.peek()
-	if kind == lexer.Identifier {
+	tok := p.current()
+	if tok.Kind == lexer.Identifier || tok.IsKeyword() {
 		return p.next()
 	}
-	for _, k := range allowedIdentifiers {
-		if kind == k {
-			return p.next()
-		}
-	}
-	panic(expectedToken(p.Tokens[p.Pos], lexer.Identifier))
+	panic(expectedToken(tok, lexer.Identifier))
 }
 
-func (p *parser) expectOrEof(kind lexer.Kind) lexer.Token {
-	k := p.peek()
-	if k == kind || k == lexer.EOF {
+// expectEndOfStmt consumes the line break or colon that ends a statement.
+// The last statement in a file may also end at EOF.
+func (p *parser) expectEndOfStmt() lexer.Token {
+	switch p.peek() {
+	case lexer.LineBreak, lexer.Colon, lexer.EOF:
 		return p.next()
 	}
-	panic(expectedToken(p.Tokens[p.Pos], kind))
+	panic(expectedToken(p.current(), lexer.LineBreak))
 }
 
 func expectedToken(tok lexer.Token, expected lexer.Kind) string {
@@ -78,5 +173,5 @@ func unexpectedToken(tok lexer.Token) string {
 }
 
 func (p *parser) unexpected() string {
-	return unexpectedToken(p.Tokens[p.Pos])
+	return unexpectedToken(p.current())
 }
@@ -1,9 +1,14 @@
 package parser
 
 import (
+	"fmt"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/guthius/vb6/ast"
 	"github.com/guthius/vb6/lexer"
 )
 
@@ -25,6 +30,19 @@ func TestParse(t *testing.T) {
 		{"exit function", "testcases/11_exit_function.bas"},
 		{"for", "testcases/12_for.bas"},
 		{"sub", "testcases/13_sub.bas"},
+		{"numbers", "testcases/14_numbers.bas"},
+		{"dates", "testcases/15_dates.bas"},
+		{"type suffixes", "testcases/16_type_suffixes.bas"},
+		{"labels", "testcases/17_labels.bas"},
+		{"member names", "testcases/18_member_names.bas"},
+		{"operators", "testcases/19_operators.bas"},
+		{"unary", "testcases/20_unary.bas"},
+		{"set and let", "testcases/21_set_let.bas"},
+		{"select", "testcases/22_select.bas"},
+		{"loops", "testcases/23_loops.bas"},
+		{"with", "testcases/24_with.bas"},
+		{"enums", "testcases/25_enums.bas"},
+		{"procedures", "testcases/26_procedures.bas"},
 	}
 
 	for _, c := range cases {
@@ -32,7 +50,582 @@ func TestParse(t *testing.T) {
 			bytes, _ := os.ReadFile(c.filename)
 			tokens := lexer.Tokenize(string(bytes))
 
-			Parse(tokens)
+			expected := Parse(tokens)
+			actual := ParseScanner(lexer.NewScanner(string(bytes), lexer.Options{}))
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("ParseScanner and Parse disagree:\n%+v\n%+v", actual, expected)
+			}
+		})
+	}
+}
+
+func TestParseNumberTypes(t *testing.T) {
+	cases := []struct {
+		source   string
+		value    float64
+		dataType ast.DataType
+	}{
+		{"1", 1, ast.DtInteger},
+		{"40000", 40000, ast.DtLong},
+		{"3000000000", 3000000000, ast.DtDouble},
+		{"1.5", 1.5, ast.DtDouble},
+		{"1D2", 100, ast.DtDouble},
+		{"1!", 1, ast.DtSingle},
+		{"1@", 1, ast.DtCurrency},
+		{"&HFF", 255, ast.DtInteger},
+		{"&HFFFF", -1, ast.DtInteger},
+		{"&HFFFF&", 65535, ast.DtLong},
+		{"&HFFFFFFFF", -1, ast.DtLong},
+		{"&O17", 15, ast.DtInteger},
+		{"32767%", 32767, ast.DtInteger},
+		{"2147483647&", 2147483647, ast.DtLong},
+	}
+
+	for _, c := range cases {
+		t.Run(c.source, func(t *testing.T) {
+			n := parseTestExpr(c.source).(ast.NumberExpr)
+			if n.Value != c.value || n.Type != c.dataType {
+				t.Errorf("expected %v (type %d), got %v (type %d)", c.value, c.dataType, n.Value, n.Type)
+			}
+		})
+	}
+}
+
+func TestParseNumberOverflow(t *testing.T) {
+	cases := []struct {
+		source  string
+		message string
+	}{
+		{"32768%", "overflow in Integer literal 32768"},
+		{"40000%", "overflow in Integer literal 40000"},
+		{"3000000000&", "overflow in Long literal 3000000000"},
+		{"&H10000%", "overflow in Integer literal 10000"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.source, func(t *testing.T) {
+			defer func() {
+				err, ok := recover().(error)
+				if !ok || err.Error() != c.message {
+					t.Errorf("expected %q, got %v", c.message, err)
+				}
+			}()
+			parseTestExpr(c.source)
+		})
+	}
+}
+
+func TestParseTypeSuffixDecl(t *testing.T) {
+	cases := []struct {
+		implicit string
+		explicit string
+	}{
+		{"Dim s$", "Dim s As String"},
+		{"Dim c@", "Dim c As Currency"},
+		{"Dim c@", "Dim c As CURRENCY"},
+		{"Dim v", "Dim v As Variant"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.explicit, func(t *testing.T) {
+			implicit := Parse(lexer.Tokenize(c.implicit))[0].(ast.VarDeclStmt)
+			explicit := Parse(lexer.Tokenize(c.explicit))[0].(ast.VarDeclStmt)
+
+			// The spans differ, as an implicit type points at the name.
+			implicit.Type.Span, explicit.Type.Span = ast.Span{}, ast.Span{}
+			if implicit.Identifier != explicit.Identifier || implicit.Type != explicit.Type {
+				t.Errorf("expected %+v, got %+v", explicit.Type, implicit.Type)
+			}
+		})
+	}
+}
+
+func TestParseSpans(t *testing.T) {
+	source := "Sub Main()\n    x = Foo(1) + 2\nEnd Sub\n"
+	sub := Parse(lexer.Tokenize(source))[0].(ast.SubStmt)
+
+	cases := []struct {
+		name     string
+		node     interface{ Range() ast.Span }
+		expected string
+	}{
+		{"sub", sub, "Sub Main()\n    x = Foo(1) + 2\nEnd Sub"},
+		{"statement", sub.Body[0], "x = Foo(1) + 2"},
+		{"binary", sub.Body[0].(ast.AssignStmt).Value, "Foo(1) + 2"},
+		{"call", sub.Body[0].(ast.AssignStmt).Value.(ast.BinaryExpr).Left, "Foo(1)"},
+		{"block", sub.Body, "x = Foo(1) + 2"},
+	}
+
+	grouped := "x = (a + b) * 2\ny = (a + b).c\n"
+	block := Parse(lexer.Tokenize(grouped))
+	product := block[0].(ast.AssignStmt).Value.(ast.BinaryExpr)
+	member := block[1].(ast.AssignStmt).Value.(ast.MemberExpr)
+	groupedCases := []struct {
+		name     string
+		node     interface{ Range() ast.Span }
+		expected string
+	}{
+		{"grouped operand", product, "(a + b) * 2"},
+		{"group", product.Left, "(a + b)"},
+		{"group inner", product.Left.(ast.GroupExpr).Inner, "a + b"},
+		{"grouped object", member, "(a + b).c"},
+	}
+	for _, c := range groupedCases {
+		t.Run(c.name, func(t *testing.T) {
+			span := c.node.Range()
+			if actual := grouped[span.Start:span.End]; actual != c.expected {
+				t.Errorf("expected %q, got %q", c.expected, actual)
+			}
+		})
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			span := c.node.Range()
+			if actual := source[span.Start:span.End]; actual != c.expected {
+				t.Errorf("expected %q, got %q", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	source := "ErrHandler:\n100 x = 1\n200: y = 2: z = 3\n1 + 2\n30\n"
+	block := Parse(lexer.Tokenize(source))
+
+	expected := []string{"LabelStmt ErrHandler:", "LabelStmt 100", "AssignStmt x = 1", "LabelStmt 200:", "AssignStmt y = 2", "AssignStmt z = 3", "ExprStmt 1 + 2", "LabelStmt 30"}
+	if len(block) != len(expected) {
+		t.Fatalf("expected %d statements, got %d: %+v", len(expected), len(block), block)
+	}
+	for i, stmt := range block {
+		name := strings.TrimPrefix(reflect.TypeOf(stmt).String(), "ast.")
+		span := stmt.Range()
+		if actual := name + " " + source[span.Start:span.End]; actual != expected[i] {
+			t.Errorf("statement %d: expected %q, got %q", i, expected[i], actual)
+		}
+	}
+
+	if label := block[0].(ast.LabelStmt).Label; label != "ErrHandler" {
+		t.Errorf("expected label ErrHandler, got %q", label)
+	}
+}
+
+func TestParseStmtSeparators(t *testing.T) {
+	typ := Parse(lexer.Tokenize("Type T: a As Long: b(1 To 2) As Integer\nEnd Type"))[0].(ast.TypeStmt)
+	if len(typ.Fields) != 2 || typ.Fields[0].Identifier != "a" || typ.Fields[1].Identifier != "b" {
+		t.Errorf("expected fields a and b, got %+v", typ.Fields)
+	}
+
+	block := Parse(lexer.Tokenize("Call Foo(1): x = 2"))
+	if len(block) != 2 {
+		t.Fatalf("expected 2 statements, got %+v", block)
+	}
+	if _, ok := block[0].(ast.CallStmt); !ok {
+		t.Errorf("expected a Call statement, got %+v", block[0])
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a statement after Call without a separator to fail")
+		}
+	}()
+	Parse(lexer.Tokenize("Call Foo(1) x = 2"))
+}
+
+func TestParseKeywordNames(t *testing.T) {
+	typ := Parse(lexer.Tokenize("Type T\n    Loop As Integer\nEnd Type"))[0].(ast.TypeStmt)
+	if typ.Fields[0].Identifier != "Loop" {
+		t.Errorf("expected a field named Loop, got %q", typ.Fields[0].Identifier)
+	}
+
+	expr := Parse(lexer.Tokenize("obj.Loop"))[0].(ast.ExprStmt).Expr.(ast.MemberExpr)
+	if expr.Member != "Loop" {
+		t.Errorf("expected member Loop, got %q", expr.Member)
+	}
+}
+
+func TestParseOperatorPrecedence(t *testing.T) {
+	parse := func(source string) ast.Expr {
+		return parseTestExpr(source)
+	}
+
+	// Imp binds more loosely than Eqv, which binds more loosely than Or.
+	imp := parse("a Or b Eqv c Imp d").(ast.BinaryExpr)
+	if imp.Operator.Kind != lexer.Imp || imp.Left.(ast.BinaryExpr).Operator.Kind != lexer.Eqv {
+		t.Errorf("expected (a Or b Eqv c) Imp d, got %+v", imp)
+	}
+
+	and := parse("s Like p And obj Is Nothing").(ast.BinaryExpr)
+	if and.Operator.Kind != lexer.And ||
+		and.Left.(ast.BinaryExpr).Operator.Kind != lexer.Like ||
+		and.Right.(ast.BinaryExpr).Operator.Kind != lexer.Is {
+		t.Errorf("expected (s Like p) And (obj Is Nothing), got %+v", and)
+	}
+
+	typeOf := parse("TypeOf ctl Is VB.TextBox And x").(ast.BinaryExpr).Left.(ast.TypeOfExpr)
+	if typeOf.Object.(ast.SymbolExpr).Name != "ctl" || typeOf.TypeName != "VB.TextBox" {
+		t.Errorf("unexpected TypeOf expression %+v", typeOf)
+	}
+
+	addressOf := parse("AddressOf modSubclass.WindowProc").(ast.AddressOfExpr)
+	if addressOf.Identifier != "modSubclass.WindowProc" {
+		t.Errorf("unexpected AddressOf expression %+v", addressOf)
+	}
+}
+
+// parseTestExpr parses the source as a single expression, outside of any
+// statement, so that a leading '=' is a comparison.
+func parseTestExpr(source string) ast.Expr {
+	return parseExpr(newParser(sliceSource(lexer.Tokenize(source))), defaultBindingPower)
+}
+
+// formatExpr renders an expression with every operation in parentheses, to
+// make the shape of the tree easy to compare.
+func formatExpr(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case ast.BinaryExpr:
+		return "(" + formatExpr(e.Left) + " " + e.Operator.Value + " " + formatExpr(e.Right) + ")"
+	case ast.UnaryExpr:
+		return "(" + e.Operator.Value + " " + formatExpr(e.Operand) + ")"
+	case ast.MemberExpr:
+		return formatExpr(e.Object) + "." + e.Member
+	case ast.ImplicitMemberExpr:
+		return "." + e.Member
+	case ast.IndexOrCallExpr:
+		args := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = formatExpr(arg)
+		}
+		return formatExpr(e.Callee) + "(" + strings.Join(args, ", ") + ")"
+	case ast.GroupExpr:
+		return formatExpr(e.Inner)
+	case ast.SymbolExpr:
+		return e.Name
+	case ast.NumberExpr:
+		return strconv.FormatFloat(e.Value, 'g', -1, 64)
+	default:
+		return fmt.Sprintf("%T", expr)
+	}
+}
+
+func TestParseUnaryExpr(t *testing.T) {
+	cases := []struct {
+		source   string
+		expected string
+	}{
+		{"-1", "(- 1)"},
+		{"+a", "(+ a)"},
+		{"--a", "(- (- a))"},
+		{"-a * b", "((- a) * b)"},
+		{"a * -b", "(a * (- b))"},
+		{"-a ^ 2", "(- (a ^ 2))"},
+		{"a ^ -2", "(a ^ (- 2))"},
+		{"-a.b", "(- a.b)"},
+		{"-a(1) ^ 2", "(- (a(1) ^ 2))"},
+		{"Not a = b", "(Not (a = b))"},
+		{"Not a And b", "((Not a) And b)"},
+		{"Not a + 1 < b Or c", "((Not ((a + 1) < b)) Or c)"},
+		{"a = Not b", "(a = (Not b))"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.source, func(t *testing.T) {
+			expr := parseTestExpr(c.source)
+			if actual := formatExpr(expr); actual != c.expected {
+				t.Errorf("expected %s, got %s", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseMemberAndIndexChains(t *testing.T) {
+	cases := []struct {
+		source   string
+		expected string
+	}{
+		{"Player(Index).Password", "Player(Index).Password"},
+		{"Map(GetPlayerMap(i)).Tile(x, y).Type", "Map(GetPlayerMap(i)).Tile(x, y).Type"},
+		{"Foo()", "Foo()"},
+		{"a.b.c(1)(2)", "a.b.c(1)(2)"},
+		{"(a + b).c", "(a + b).c"},
+		{"x.y + f(1) * 2", "(x.y + (f(1) * 2))"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.source, func(t *testing.T) {
+			expr := parseTestExpr(c.source)
+			if actual := formatExpr(expr); actual != c.expected {
+				t.Errorf("expected %s, got %s", c.expected, actual)
+			}
+		})
+	}
+
+	source := "Map(GetPlayerMap(i)).Tile(x, y).Type"
+	member := Parse(lexer.Tokenize(source))[0].(ast.ExprStmt).Expr.(ast.MemberExpr)
+	tile := member.Object.(ast.IndexOrCallExpr)
+	if tile.Callee.(ast.MemberExpr).Member != "Tile" || len(tile.Args) != 2 {
+		t.Errorf("unexpected Tile(x, y) expression %+v", tile)
+	}
+	if span := tile.Range(); source[span.Start:span.End] != "Map(GetPlayerMap(i)).Tile(x, y)" {
+		t.Errorf("unexpected span %q", source[span.Start:span.End])
+	}
+}
+
+func TestParseAssignments(t *testing.T) {
+	block := Parse(lexer.Tokenize("x = y = z\nSet rs = New Recordset\nLet a(1).b = -c\nFoo(x) < 1"))
+
+	assign := block[0].(ast.AssignStmt)
+	if formatExpr(assign.Target) != "x" || formatExpr(assign.Value) != "(y = z)" {
+		t.Errorf("expected x to be assigned (y = z), got %+v", assign)
+	}
+
+	set := block[1].(ast.SetStmt)
+	if formatExpr(set.Target) != "rs" || set.Value.(ast.NewExpr).TypeName != "Recordset" {
+		t.Errorf("unexpected Set statement %+v", set)
+	}
+
+	let := block[2].(ast.LetStmt)
+	if formatExpr(let.Target) != "a(1).b" || formatExpr(let.Value) != "(- c)" {
+		t.Errorf("unexpected Let statement %+v", let)
+	}
+
+	// Only '=' makes an assignment; any other operator continues the
+	// expression.
+	expr := block[3].(ast.ExprStmt)
+	if formatExpr(expr.Expr) != "(Foo(x) < 1)" {
+		t.Errorf("unexpected expression statement %+v", expr)
+	}
+}
+
+func TestParseSelectStmt(t *testing.T) {
+	source := "Select Case x\nCase 1, 2 To 5, Is > 10\n    a = 1\nCase \"a\"\nCase Else\n    a = 2\nEnd Select\n"
+	stmt := Parse(lexer.Tokenize(source))[0].(ast.SelectStmt)
+
+	if formatExpr(stmt.Expr) != "x" || len(stmt.Cases) != 3 {
+		t.Fatalf("unexpected Select statement %+v", stmt)
+	}
+
+	tests := stmt.Cases[0].Tests
+	if len(tests) != 3 || len(stmt.Cases[0].Body) != 1 {
+		t.Fatalf("unexpected first Case %+v", stmt.Cases[0])
+	}
+	if formatExpr(tests[0]) != "1" {
+		t.Errorf("expected the value 1, got %+v", tests[0])
+	}
+	if r := tests[1].(ast.RangeExpr); formatExpr(r.LBound) != "2" || formatExpr(r.UBound) != "5" {
+		t.Errorf("expected the range 2 To 5, got %+v", r)
+	}
+	if is := tests[2].(ast.CaseIsExpr); is.Operator.Kind != lexer.GreaterThan || formatExpr(is.Value) != "10" {
+		t.Errorf("expected Is > 10, got %+v", is)
+	}
+
+	if len(stmt.Cases[1].Body) != 0 {
+		t.Errorf("expected an empty second Case, got %+v", stmt.Cases[1])
+	}
+	if !stmt.Cases[2].IsElse || len(stmt.Cases[2].Body) != 1 {
+		t.Errorf("expected Case Else, got %+v", stmt.Cases[2])
+	}
+	if span := stmt.Range(); source[span.Start:span.End] != strings.TrimSuffix(source, "\n") {
+		t.Errorf("unexpected span %q", source[span.Start:span.End])
+	}
+}
+
+func TestParseLoops(t *testing.T) {
+	cases := []struct {
+		source     string
+		condition  string
+		isPostTest bool
+		isUntil    bool
+	}{
+		{"Do While a < 1\n    x = 1\nLoop", "(a < 1)", false, false},
+		{"Do Until a\n    x = 1\nLoop", "a", false, true},
+		{"Do\n    x = 1\nLoop While a", "a", true, false},
+		{"Do\n    x = 1\nLoop Until a", "a", true, true},
+		{"Do\n    Exit Do\nLoop", "", false, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.source, func(t *testing.T) {
+			stmt := Parse(lexer.Tokenize(c.source))[0].(ast.DoStmt)
+
+			condition := ""
+			if stmt.Condition != nil {
+				condition = formatExpr(stmt.Condition)
+			}
+			if condition != c.condition || stmt.IsPostTest != c.isPostTest || stmt.IsUntil != c.isUntil || len(stmt.Body) != 1 {
+				t.Errorf("unexpected loop %+v", stmt)
+			}
+			if span := stmt.Range(); c.source[span.Start:span.End] != c.source {
+				t.Errorf("unexpected span %q", c.source[span.Start:span.End])
+			}
+		})
+	}
+
+	while := Parse(lexer.Tokenize("While a\n    x = 1\nWend"))[0].(ast.WhileStmt)
+	if formatExpr(while.Condition) != "a" || len(while.Body) != 1 {
+		t.Errorf("unexpected While loop %+v", while)
+	}
+}
+
+func TestParseWithStmt(t *testing.T) {
+	source := "With Player(Index).Char(CharNum)\n    .Name = x\n    With .Inv(1)\n        .Num = .Num + 1\n    End With\nEnd With"
+	stmt := Parse(lexer.Tokenize(source))[0].(ast.WithStmt)
+
+	if formatExpr(stmt.Object) != "Player(Index).Char(CharNum)" || len(stmt.Body) != 2 {
+		t.Fatalf("unexpected With statement %+v", stmt)
+	}
+	if assign := stmt.Body[0].(ast.AssignStmt); formatExpr(assign.Target) != ".Name" {
+		t.Errorf("expected an assignment to .Name, got %+v", assign)
+	}
+
+	inner := stmt.Body[1].(ast.WithStmt)
+	if formatExpr(inner.Object) != ".Inv(1)" || len(inner.Body) != 1 {
+		t.Fatalf("unexpected nested With statement %+v", inner)
+	}
+	if assign := inner.Body[0].(ast.AssignStmt); formatExpr(assign.Value) != "(.Num + 1)" {
+		t.Errorf("unexpected assignment %+v", assign)
+	}
+	if span := stmt.Range(); source[span.Start:span.End] != source {
+		t.Errorf("unexpected span %q", source[span.Start:span.End])
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected .Name outside of a With block to fail")
+		}
+	}()
+	Parse(lexer.Tokenize(".Name = x"))
+}
+
+func TestParseEnumStmt(t *testing.T) {
+	source := "Private Enum Direction\n    DIR_UP = -1\n    DIR_DOWN\n\n    DIR_LEFT = DIR_UP Or 2\n    DIR_RIGHT\nEnd Enum"
+	stmt := Parse(lexer.Tokenize(source))[0].(ast.EnumStmt)
+
+	if stmt.Public || stmt.Identifier != "Direction" || len(stmt.Members) != 4 {
+		t.Fatalf("unexpected Enum statement %+v", stmt)
+	}
+	if stmt.Members[1].Identifier != "DIR_DOWN" || stmt.Members[1].Value != nil {
+		t.Errorf("expected DIR_DOWN without a value, got %+v", stmt.Members[1])
+	}
+
+	values := stmt.Values()
+	expected := []string{"(- 1)", "0", "(DIR_UP Or 2)", "(DIR_LEFT + 1)"}
+	for i, value := range values {
+		if actual := formatExpr(value); actual != expected[i] {
+			t.Errorf("expected %s = %s, got %s", stmt.Members[i].Identifier, expected[i], actual)
+		}
+	}
+	if span := stmt.Range(); source[span.Start:span.End] != source {
+		t.Errorf("unexpected span %q", source[span.Start:span.End])
+	}
+
+	implicit := Parse(lexer.Tokenize("Enum Stats\n    Strength\n    Endurance = 5\n    Vitality\nEnd Enum"))[0].(ast.EnumStmt)
+	if !implicit.Public {
+		t.Errorf("expected an Enum without Public or Private to be public")
+	}
+	expected = []string{"0", "5", "6"}
+	for i, value := range implicit.Values() {
+		if actual := formatExpr(value); actual != expected[i] {
+			t.Errorf("expected %s = %s, got %s", implicit.Members[i].Identifier, expected[i], actual)
+		}
+	}
+}
+
+func TestParseProcedureModifiers(t *testing.T) {
+	cases := []struct {
+		source string
+		public bool
+		friend bool
+		static bool
+	}{
+		{"Sub Foo()\nEnd Sub", true, false, false},
+		{"Public Sub Foo()\nEnd Sub", true, false, false},
+		{"Private Sub Foo()\nEnd Sub", false, false, false},
+		{"Friend Sub Foo()\nEnd Sub", false, true, false},
+		{"Static Sub Foo()\nEnd Sub", true, false, true},
+		{"Private Static Function Foo() As Long\nEnd Function", false, false, true},
+		{"Public Function Foo() As Long\nEnd Function", true, false, false},
+		{"Friend Static Property Get Foo() As Long\nEnd Property", false, true, true},
+		{"Private Property Let Foo(ByVal Value As Long)\nEnd Property", false, false, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.source, func(t *testing.T) {
+			var public, friend, static bool
+			switch stmt := Parse(lexer.Tokenize(c.source))[0].(type) {
+			case ast.SubStmt:
+				public, friend, static = stmt.Public, stmt.Friend, stmt.Static
+			case ast.FunctionStmt:
+				public, friend, static = stmt.Public, stmt.Friend, stmt.Static
+			case ast.PropertyStmt:
+				public, friend, static = stmt.Public, stmt.Friend, stmt.Static
+			default:
+				t.Fatalf("unexpected statement %+v", stmt)
+			}
+
+			if public != c.public || friend != c.friend || static != c.static {
+				t.Errorf("expected Public=%t Friend=%t Static=%t, got Public=%t Friend=%t Static=%t",
+					c.public, c.friend, c.static, public, friend, static)
+			}
+		})
+	}
+}
+
+func TestParsePropertyStmt(t *testing.T) {
+	source := "Public Property Get Name() As String\n    Exit Property\nEnd Property\n" +
+		"Public Property Let Name(ByVal Value As String)\nEnd Property\n" +
+		"Public Property Set Socket(ByVal Value As Object)\nEnd Property"
+	block := Parse(lexer.Tokenize(source))
+
+	get := block[0].(ast.PropertyStmt)
+	if get.Kind != ast.PropertyGet || get.Identifier != "Name" || get.ReturnType.Type != ast.DtString || len(get.Args) != 0 {
+		t.Errorf("unexpected Property Get %+v", get)
+	}
+	if _, ok := get.Body[0].(ast.ExitPropertyStmt); !ok {
+		t.Errorf("expected Exit Property, got %+v", get.Body[0])
+	}
+	if span := get.Range(); !strings.HasPrefix(source[span.Start:], "Public Property Get") || !strings.HasSuffix(source[:span.End], "End Property") {
+		t.Errorf("unexpected span %q", source[span.Start:span.End])
+	}
+
+	let := block[1].(ast.PropertyStmt)
+	if let.Kind != ast.PropertyLet || len(let.Args) != 1 || let.Args[0].Identifier != "Value" {
+		t.Errorf("unexpected Property Let %+v", let)
+	}
+	if set := block[2].(ast.PropertyStmt); set.Kind != ast.PropertySet || set.Identifier != "Socket" {
+		t.Errorf("unexpected Property Set %+v", set)
+	}
+}
+
+func TestParseWithDetachedDot(t *testing.T) {
+	cases := []struct {
+		source   string
+		expected string
+	}{
+		{"x = Foo.b", "Foo.b"},
+		{"x = Foo _\n        .b", "Foo.b"},
+		{"x = Foo + .b", "(Foo + .b)"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.source, func(t *testing.T) {
+			stmt := Parse(lexer.Tokenize("With a\n    " + c.source + "\nEnd With"))[0].(ast.WithStmt)
+			if actual := formatExpr(stmt.Body[0].(ast.AssignStmt).Value); actual != c.expected {
+				t.Errorf("expected %s, got %s", c.expected, actual)
+			}
+		})
+	}
+
+	// A dot after a space starts an implicit member access, so it cannot
+	// continue the expression before it.
+	for _, source := range []string{"x = Foo .b", "Debug.Print .Name"} {
+		t.Run(source, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected %q to fail", source)
+				}
+			}()
+			Parse(lexer.Tokenize("With a\n    " + source + "\nEnd With"))
 		})
 	}
 }
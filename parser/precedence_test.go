@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/guthius/vb6/lexer"
+)
+
+// referencePrecedence is the VB6 operator precedence as listed in the
+// language reference, from loosest (1) to tightest. It is kept separate from
+// the binding powers in lookup.go on purpose, so that the two can be checked
+// against each other.
+var referencePrecedence = map[lexer.Kind]int{
+	lexer.Imp:                1,
+	lexer.Eqv:                2,
+	lexer.Xor:                3,
+	lexer.Or:                 4,
+	lexer.And:                5,
+	lexer.Equal:              7,
+	lexer.NotEqual:           7,
+	lexer.LessThan:           7,
+	lexer.LessThanOrEqual:    7,
+	lexer.GreaterThan:        7,
+	lexer.GreaterThanOrEqual: 7,
+	lexer.Like:               7,
+	lexer.Is:                 7,
+	lexer.Concat:             8,
+	lexer.Add:                9,
+	lexer.Subtract:           9,
+	lexer.Modulus:            10,
+	lexer.DivideInt:          11,
+	lexer.Multiply:           12,
+	lexer.Divide:             12,
+	lexer.Exponent:           14,
+}
+
+const (
+	notPrecedence      = 6
+	negationPrecedence = 13
+)
+
+// referenceParser is a precedence-climbing parser for the expressions
+// produced by randomExpr. It renders the tree the same way formatExpr does.
+type referenceParser struct {
+	tokens []lexer.Token
+	pos    int
+}
+
+func (r *referenceParser) climb(min int) string {
+	left := r.operand()
+	for {
+		op := r.tokens[r.pos]
+		prec, ok := referencePrecedence[op.Kind]
+		if !ok || prec < min {
+			return left
+		}
+		r.pos++
+		left = "(" + left + " " + op.Value + " " + r.climb(prec+1) + ")"
+	}
+}
+
+func (r *referenceParser) operand() string {
+	tok := r.tokens[r.pos]
+	r.pos++
+	switch tok.Kind {
+	case lexer.Not:
+		return "(" + tok.Value + " " + r.climb(notPrecedence+1) + ")"
+	case lexer.Subtract, lexer.Add:
+		return "(" + tok.Value + " " + r.climb(negationPrecedence+1) + ")"
+	case lexer.LParen:
+		inner := r.climb(1)
+		r.pos++
+		return inner
+	default:
+		return tok.Value
+	}
+}
+
+var randomOperators = []string{
+	"Imp", "Eqv", "Xor", "Or", "And",
+	"=", "<>", "<", "<=", ">", ">=", "Like", "Is",
+	"&", "+", "-", "Mod", "\\", "*", "/", "^",
+}
+
+// randomExpr builds a random expression of operands joined by binary
+// operators, with unary operators and parentheses sprinkled in.
+func randomExpr(rng *rand.Rand, depth int) string {
+	var b strings.Builder
+	for i := range rng.IntN(5) + 1 {
+		if i > 0 {
+			b.WriteString(" " + randomOperators[rng.IntN(len(randomOperators))] + " ")
+		}
+
+		switch rng.IntN(8) {
+		case 0:
+			b.WriteString("Not ")
+		case 1:
+			b.WriteString("-")
+		case 2:
+			b.WriteString("+")
+		}
+
+		if depth > 0 && rng.IntN(4) == 0 {
+			b.WriteString("(" + randomExpr(rng, depth-1) + ")")
+		} else {
+			b.WriteByte(byte('a' + rng.IntN(26)))
+		}
+	}
+	return b.String()
+}
+
+func TestOperatorPrecedenceMatchesReference(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for range 2000 {
+		source := randomExpr(rng, 3)
+		tokens := lexer.Tokenize(source)
+
+		reference := &referenceParser{tokens: tokens}
+		expected := reference.climb(1)
+
+		actual := formatExpr(parseExpr(newParser(sliceSource(tokens)), defaultBindingPower))
+		if actual != expected {
+			t.Fatalf("%s\nexpected %s\ngot      %s", source, expected, actual)
+		}
+	}
+}
+
+func TestOperatorPrecedence(t *testing.T) {
+	cases := []struct {
+		source   string
+		expected string
+	}{
+		{"2 ^ 3 ^ 2", "((2 ^ 3) ^ 2)"},
+		{"a * b \\ c", "((a * b) \\ c)"},
+		{"a \\ b * c", "(a \\ (b * c))"},
+		{"a Mod b \\ c", "(a Mod (b \\ c))"},
+		{"a + b Mod c", "(a + (b Mod c))"},
+		{"a & b + c", "(a & (b + c))"},
+		{"a = b & c", "(a = (b & c))"},
+		{"a Or b And c", "(a Or (b And c))"},
+		{"a Xor b Or c", "(a Xor (b Or c))"},
+		{"a Imp b Eqv c Xor d", "(a Imp (b Eqv (c Xor d)))"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.source, func(t *testing.T) {
+			expr := parseTestExpr(c.source)
+			if actual := formatExpr(expr); actual != c.expected {
+				t.Errorf("expected %s, got %s", c.expected, actual)
+			}
+		})
+	}
+}
@@ -1,13 +1,16 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/guthius/vb6/ast"
 	"github.com/guthius/vb6/lexer"
 )
 
-func (p *parser) skipLineBreaks() {
+// skipSeparators skips blank lines and stray colons between statements.
+func (p *parser) skipSeparators() {
 	for !p.isEof() {
-		if p.peek() != lexer.LineBreak {
+		if k := p.peek(); k != lexer.LineBreak && k != lexer.Colon {
 			break
 		}
 		p.next()
@@ -15,24 +18,110 @@ func (p *parser) skipLineBreaks() {
 }
 
 func (p *parser) parseStmt() ast.Stmt {
+	if p.LineStart {
+		if label, ok := parseLabelStmt(p); ok {
+			return label
+		}
+	}
+
 	sfn, ok := tables.stmt[p.peek()]
 	if !ok {
-		expr := parseExpr(p, defaultBindingPower)
-		p.expectOrEof(lexer.LineBreak)
-		return ast.ExprStmt{Expr: expr}
+		return parseAssignOrExprStmt(p)
 	}
 
 	return sfn(p)
 }
 
-func parseConstDeclStmt(p *parser, public bool) ast.Stmt {
+// parseAssignOrExprStmt parses a statement that starts with an expression.
+// At the start of a statement '=' is an assignment rather than a comparison,
+// so the expression is first parsed up to the relational operators. If an
+// '=' follows, it was the target of an assignment; otherwise the rest of the
+// expression is parsed.
+func parseAssignOrExprStmt(p *parser) ast.Stmt {
+	start := p.pos()
+	expr := parseExpr(p, relational)
+
+	if p.peek() == lexer.Equal {
+		p.next()
+		value := parseExpr(p, assignment)
+		p.expectEndOfStmt()
+		return ast.AssignStmt{Span: p.spanFrom(start), Target: expr, Value: value}
+	}
+
+	expr = parseInfixExpr(p, expr, defaultBindingPower)
+	p.expectEndOfStmt()
+	return ast.ExprStmt{Span: p.spanFrom(start), Expr: expr}
+}
+
+// parseAssignment parses the "target = value" part of a Set or Let statement.
+func parseAssignment(p *parser) (ast.Expr, ast.Expr) {
+	target := parseExpr(p, relational)
+	p.expect(lexer.Equal)
+	value := parseExpr(p, assignment)
+	p.expectEndOfStmt()
+	return target, value
+}
+
+func parseSetStmt(p *parser) ast.Stmt {
+	start := p.pos()
+	p.expect(lexer.Set)
+	target, value := parseAssignment(p)
+	return ast.SetStmt{Span: p.spanFrom(start), Target: target, Value: value}
+}
+
+func parseLetStmt(p *parser) ast.Stmt {
+	start := p.pos()
+	p.expect(lexer.Let)
+	target, value := parseAssignment(p)
+	return ast.LetStmt{Span: p.spanFrom(start), Target: target, Value: value}
+}
+
+// parseLabelStmt parses the label at the start of a line, if there is one. A
+// label is either a name followed by a colon, as in "ErrHandler:", or a line
+// number, which may be followed by a colon or directly by a statement.
+func parseLabelStmt(p *parser) (ast.Stmt, bool) {
+	tok := p.current()
+	switch tok.Kind {
+	case lexer.Identifier:
+		if p.lookahead(1).Kind != lexer.Colon {
+			return nil, false
+		}
+	case lexer.Number:
+		if tok.Radix != 10 || tok.Suffix != lexer.NoSuffix || strings.ContainsAny(tok.Value, ".eEdD") {
+			return nil, false
+		}
+
+		// A number followed by an operator is an expression statement rather
+		// than a line number. A number alone on its line is a label, as a
+		// bare number is never a statement.
+		if _, ok := tables.led[p.lookahead(1).Kind]; ok {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	p.next()
+	end := tok.End
+	if p.peek() == lexer.Colon {
+		end = p.next().End
+	}
+
+	return ast.LabelStmt{
+		Span:  ast.Span{Start: tok.Start, End: end},
+		Label: tok.Value,
+	}, true
+}
+
+func parseConstDeclStmt(p *parser, start int, public bool) ast.Stmt {
 	p.expect(lexer.Const)
 	identifier := p.expect(lexer.Identifier).Value
 	p.expect(lexer.Equal)
 	value := parseExpr(p, assignment)
-	p.expectOrEof(lexer.LineBreak)
+	p.expectEndOfStmt()
 
 	return ast.ConstDeclStmt{
+		Span:       p.spanFrom(start),
 		Public:     public,
 		Identifier: identifier,
 		Value:      value,
@@ -40,25 +129,30 @@ func parseConstDeclStmt(p *parser, public bool) ast.Stmt {
 }
 
 func parsePrivateConstDeclStmt(p *parser) ast.Stmt {
-	return parseConstDeclStmt(p, false)
+	return parseConstDeclStmt(p, p.pos(), false)
 }
 
 func parseDeclStmt(p *parser) ast.Stmt {
+	start := p.pos()
 	public := p.next().Kind == lexer.Public
 
-	if p.peek() == lexer.Const {
-		return parseConstDeclStmt(p, public)
+	switch p.peek() {
+	case lexer.Const:
+		return parseConstDeclStmt(p, start, public)
+	case lexer.Enum:
+		return parseEnumDeclStmt(p, start, public)
+	case lexer.Static, lexer.Sub, lexer.Function, lexer.PropertyGet, lexer.PropertyLet, lexer.PropertySet:
+		return parseProcedureStmt(p, start, procModifiers{Public: public})
 	}
 
-	identifier := p.expect(lexer.Identifier).Value
+	name := p.expect(lexer.Identifier)
 
 	var ranges []ast.RangeExpr
 	if p.peek() == lexer.LParen {
 		ranges = parseRangeExpr(p)
 	}
 
-	p.expect(lexer.As)
-	dataType := parseTypeExpr(p)
+	dataType := parseDeclTypeExpr(p, name)
 
 	var value ast.Expr
 	if p.peek() == lexer.Equal {
@@ -66,11 +160,12 @@ func parseDeclStmt(p *parser) ast.Stmt {
 		value = parseExpr(p, assignment)
 	}
 
-	p.expectOrEof(lexer.LineBreak)
+	p.expectEndOfStmt()
 
 	return ast.VarDeclStmt{
+		Span:       p.spanFrom(start),
 		Public:     public,
-		Identifier: identifier,
+		Identifier: name.Value,
 		Type:       dataType,
 		IsArray:    ranges != nil,
 		Ranges:     ranges,
@@ -83,10 +178,11 @@ func parseRangeExpr(p *parser) []ast.RangeExpr {
 
 	p.expect(lexer.LParen)
 	for p.peek() != lexer.RParen {
+		start := p.pos()
 		l := parseExpr(p, assignment)
 		p.expect(lexer.To)
 		u := parseExpr(p, assignment)
-		ranges = append(ranges, ast.RangeExpr{LBound: l, UBound: u})
+		ranges = append(ranges, ast.RangeExpr{Span: p.spanFrom(start), LBound: l, UBound: u})
 		if p.peek() != lexer.Comma {
 			break
 		}
@@ -101,6 +197,7 @@ func parseRangeExpr(p *parser) []ast.RangeExpr {
 func parseFieldDeclExpr(p *parser) ast.FieldDeclExpr {
 	var ranges []ast.RangeExpr
 
+	start := p.pos()
 	identifier := p.expectIdentifier().Value
 
 	if p.peek() == lexer.LParen {
@@ -109,9 +206,10 @@ func parseFieldDeclExpr(p *parser) ast.FieldDeclExpr {
 
 	p.expect(lexer.As)
 	dataType := parseTypeExpr(p)
-	p.expect(lexer.LineBreak)
+	p.expectEndOfStmt()
 
 	return ast.FieldDeclExpr{
+		Span:       p.spanFrom(start),
 		Identifier: identifier,
 		Type:       dataType,
 		IsArray:    ranges != nil,
@@ -120,13 +218,14 @@ func parseFieldDeclExpr(p *parser) ast.FieldDeclExpr {
 }
 
 func parseTypeStmt(p *parser) ast.Stmt {
+	start := p.pos()
 	p.expect(lexer.Type)
 	identifier := p.expect(lexer.Identifier).Value
-	p.next()
+	p.expectEndOfStmt()
 
 	fields := make([]ast.FieldDeclExpr, 0)
 	for {
-		p.skipLineBreaks()
+		p.skipSeparators()
 
 		if p.peek() == lexer.EndType {
 			break
@@ -138,12 +237,63 @@ func parseTypeStmt(p *parser) ast.Stmt {
 	p.expect(lexer.EndType)
 
 	return ast.TypeStmt{
+		Span:       p.spanFrom(start),
 		Identifier: identifier,
 		Fields:     fields,
 	}
 }
 
+func parseEnumDeclStmt(p *parser, start int, public bool) ast.Stmt {
+	p.expect(lexer.Enum)
+	identifier := p.expect(lexer.Identifier).Value
+	p.expectEndOfStmt()
+
+	members := make([]ast.EnumMemberExpr, 0)
+	for {
+		p.skipSeparators()
+
+		if p.peek() == lexer.EndEnum {
+			break
+		}
+
+		members = append(members, parseEnumMemberExpr(p))
+	}
+
+	p.expect(lexer.EndEnum)
+
+	return ast.EnumStmt{
+		Span:       p.spanFrom(start),
+		Public:     public,
+		Identifier: identifier,
+		Members:    members,
+	}
+}
+
+func parsePublicEnumDeclStmt(p *parser) ast.Stmt {
+	return parseEnumDeclStmt(p, p.pos(), true)
+}
+
+func parseEnumMemberExpr(p *parser) ast.EnumMemberExpr {
+	start := p.pos()
+	identifier := p.expect(lexer.Identifier).Value
+
+	var value ast.Expr
+	if p.peek() == lexer.Equal {
+		p.next()
+		value = parseExpr(p, assignment)
+	}
+
+	p.expectEndOfStmt()
+
+	return ast.EnumMemberExpr{
+		Span:       p.spanFrom(start),
+		Identifier: identifier,
+		Value:      value,
+	}
+}
+
 func parseCallStmt(p *parser) ast.Stmt {
+	start := p.pos()
 	p.expect(lexer.Call)
 	funcName := p.expect(lexer.Identifier).Value
 	p.expect(lexer.LParen)
@@ -158,17 +308,20 @@ func parseCallStmt(p *parser) ast.Stmt {
 	}
 
 	p.expect(lexer.RParen)
+	p.expectEndOfStmt()
 
 	return ast.CallStmt{
+		Span:       p.spanFrom(start),
 		Identifier: funcName,
 		Args:       args,
 	}
 }
 
 func parseDeclareStmt(p *parser) ast.Stmt {
+	start := p.pos()
 	p.expect(lexer.Declare)
 	p.expect(lexer.Function)
-	identifier := p.expect(lexer.Identifier).Value
+	name := p.expect(lexer.Identifier)
 	p.expect(lexer.Lib)
 	lib := p.expect(lexer.String).Value
 	p.expect(lexer.Alias)
@@ -177,16 +330,12 @@ func parseDeclareStmt(p *parser) ast.Stmt {
 	args := parseArgList(p)
 	p.expect(lexer.RParen)
 
-	var returnType ast.TypeExpr
-	if p.peek() == lexer.As {
-		p.next()
-		returnType = parseTypeExpr(p)
-	}
-
-	p.expectOrEof(lexer.LineBreak)
+	returnType := parseDeclTypeExpr(p, name)
+	p.expectEndOfStmt()
 
 	return ast.DeclareStmt{
-		Identifier: identifier,
+		Span:       p.spanFrom(start),
+		Identifier: name.Value,
 		Lib:        lib,
 		Alias:      alias,
 		Args:       args,
@@ -197,6 +346,7 @@ func parseDeclareStmt(p *parser) ast.Stmt {
 func parseArgList(p *parser) []ast.ArgExpr {
 	args := make([]ast.ArgExpr, 0)
 	for p.peek() != lexer.RParen {
+		start := p.pos()
 		byRef := false
 		switch p.peek() {
 		case lexer.ByVal:
@@ -206,13 +356,12 @@ func parseArgList(p *parser) []ast.ArgExpr {
 			p.next()
 		}
 
-		name := p.expect(lexer.Identifier).Value
-		p.expect(lexer.As)
-
-		argType := parseTypeExpr(p)
+		name := p.expect(lexer.Identifier)
+		argType := parseDeclTypeExpr(p, name)
 		args = append(args, ast.ArgExpr{
+			Span:       p.spanFrom(start),
 			ByRef:      byRef,
-			Identifier: name,
+			Identifier: name.Value,
 			Type:       argType,
 		})
 
@@ -226,21 +375,63 @@ func parseArgList(p *parser) []ast.ArgExpr {
 	return args
 }
 
-func parseFunctionStmt(p *parser) ast.Stmt {
+// procModifiers are the modifiers in front of a Sub, Function or Property
+// procedure.
+type procModifiers struct {
+	Public bool
+	Friend bool
+	Static bool
+}
+
+// parseProcedureStmt parses a procedure after its Public, Private or Friend
+// modifier, if it has one. start is the offset of that modifier.
+func parseProcedureStmt(p *parser, start int, modifiers procModifiers) ast.Stmt {
+	if p.peek() == lexer.Static {
+		p.next()
+		modifiers.Static = true
+	}
+
+	switch p.peek() {
+	case lexer.Sub:
+		return parseSubStmt(p, start, modifiers)
+	case lexer.Function:
+		return parseFunctionStmt(p, start, modifiers)
+	case lexer.PropertyGet, lexer.PropertyLet, lexer.PropertySet:
+		return parsePropertyStmt(p, start, modifiers)
+	default:
+		panic(p.unexpected())
+	}
+}
+
+// parsePublicProcedureStmt parses a procedure without a Public, Private or
+// Friend modifier, which makes it public.
+func parsePublicProcedureStmt(p *parser) ast.Stmt {
+	return parseProcedureStmt(p, p.pos(), procModifiers{Public: true})
+}
+
+func parseFriendProcedureStmt(p *parser) ast.Stmt {
+	start := p.pos()
+	p.expect(lexer.Friend)
+	return parseProcedureStmt(p, start, procModifiers{Friend: true})
+}
+
+func parseFunctionStmt(p *parser, start int, modifiers procModifiers) ast.Stmt {
 	p.expect(lexer.Function)
-	identifier := p.expect(lexer.Identifier).Value
+	name := p.expect(lexer.Identifier)
 	p.expect(lexer.LParen)
 	args := parseArgList(p)
 	p.expect(lexer.RParen)
-	p.expect(lexer.As)
-	returnType := parseTypeExpr(p)
-	p.expectOrEof(lexer.LineBreak)
+	returnType := parseDeclTypeExpr(p, name)
+	p.expectEndOfStmt()
 	body := parseBlockStmt(p, lexer.EndFunction)
 	p.expect(lexer.EndFunction)
 
 	return ast.FunctionStmt{
-		Public:     true,
-		Identifier: identifier,
+		Span:       p.spanFrom(start),
+		Public:     modifiers.Public,
+		Friend:     modifiers.Friend,
+		Static:     modifiers.Static,
+		Identifier: name.Value,
 		Args:       args,
 		ReturnType: returnType,
 		Body:       body,
@@ -250,42 +441,41 @@ func parseFunctionStmt(p *parser) ast.Stmt {
 func parseDimChain(p *parser, block ast.BlockStmt) ast.Stmt {
 	for p.peek() == lexer.Comma {
 		p.expect(lexer.Comma)
-		identifier := p.expect(lexer.Identifier).Value
-		p.expect(lexer.As)
-		dataType := parseTypeExpr(p)
+		start := p.pos()
+		name := p.expect(lexer.Identifier)
+		dataType := parseDeclTypeExpr(p, name)
 
 		block = append(block, ast.VarDeclStmt{
+			Span:       p.spanFrom(start),
 			Public:     false,
-			Identifier: identifier,
+			Identifier: name.Value,
 			Type:       dataType,
 		})
-
-		if p.peek() == lexer.Comma {
-			break
-		}
 	}
-	p.expectOrEof(lexer.LineBreak)
+	p.expectEndOfStmt()
 	return block
 }
 
 func parseDimStmt(p *parser) ast.Stmt {
+	start := p.pos()
 	p.expect(lexer.Dim)
-	identifier := p.expect(lexer.Identifier).Value
-	p.expect(lexer.As)
-	dataType := parseTypeExpr(p)
+	name := p.expect(lexer.Identifier)
+	dataType := parseDeclTypeExpr(p, name)
 	if p.peek() == lexer.Comma {
 		return parseDimChain(p, ast.BlockStmt{ast.VarDeclStmt{
+			Span:       p.spanFrom(start),
 			Public:     false,
-			Identifier: identifier,
+			Identifier: name.Value,
 			Type:       dataType,
 		}})
 	}
 
-	p.expectOrEof(lexer.LineBreak)
+	p.expectEndOfStmt()
 
 	return ast.VarDeclStmt{
+		Span:       p.spanFrom(start),
 		Public:     false,
-		Identifier: identifier,
+		Identifier: name.Value,
 		Type:       dataType,
 	}
 }
@@ -294,7 +484,7 @@ func parseBlockStmt(p *parser, end ...lexer.Kind) ast.BlockStmt {
 	body := make(ast.BlockStmt, 0)
 
 	for !p.isEof() {
-		p.skipLineBreaks()
+		p.skipSeparators()
 		if p.isEof() {
 			break
 		}
@@ -312,22 +502,25 @@ func parseBlockStmt(p *parser, end ...lexer.Kind) ast.BlockStmt {
 }
 
 func parseIfStmt(p *parser) ast.Stmt {
+	start := p.pos()
 	p.expect(lexer.If)
 	condition := parseExpr(p, assignment)
 	p.expect(lexer.Then)
-	p.expectOrEof(lexer.LineBreak)
+	p.expectEndOfStmt()
 	body := parseBlockStmt(p, lexer.ElseIf, lexer.Else, lexer.EndIf)
 
 	var elseIf []ast.ElseIfStmt
 	if p.peek() == lexer.ElseIf {
 		elseIf = make([]ast.ElseIfStmt, 0)
 		for p.peek() == lexer.ElseIf {
+			elseIfStart := p.pos()
 			p.next()
 			elseIfCondition := parseExpr(p, assignment)
 			p.expect(lexer.Then)
-			p.expectOrEof(lexer.LineBreak)
+			p.expectEndOfStmt()
 			elseBody := parseBlockStmt(p, lexer.ElseIf, lexer.Else, lexer.EndIf)
 			elseIf = append(elseIf, ast.ElseIfStmt{
+				Span:      p.spanFrom(elseIfStart),
 				Condition: elseIfCondition,
 				Body:      elseBody,
 			})
@@ -343,6 +536,7 @@ func parseIfStmt(p *parser) ast.Stmt {
 	p.expect(lexer.EndIf)
 
 	return ast.IfStmt{
+		Span:      p.spanFrom(start),
 		Condition: condition,
 		Body:      body,
 		ElseIf:    elseIf,
@@ -351,12 +545,79 @@ func parseIfStmt(p *parser) ast.Stmt {
 }
 
 func parseExitFunctionStmt(p *parser) ast.Stmt {
+	start := p.pos()
 	p.expect(lexer.ExitFunction)
-	p.expectOrEof(lexer.LineBreak)
-	return ast.ExitFunctionStmt{}
+	p.expectEndOfStmt()
+	return ast.ExitFunctionStmt{Span: p.spanFrom(start)}
+}
+
+func parseExitPropertyStmt(p *parser) ast.Stmt {
+	start := p.pos()
+	p.expect(lexer.ExitProperty)
+	p.expectEndOfStmt()
+	return ast.ExitPropertyStmt{Span: p.spanFrom(start)}
+}
+
+func parseExitDoStmt(p *parser) ast.Stmt {
+	start := p.pos()
+	p.expect(lexer.ExitDo)
+	p.expectEndOfStmt()
+	return ast.ExitDoStmt{Span: p.spanFrom(start)}
+}
+
+// parseLoopCondition parses the While or Until condition after Do or Loop, if
+// there is one.
+func parseLoopCondition(p *parser) (condition ast.Expr, isUntil bool) {
+	switch p.peek() {
+	case lexer.While, lexer.Until:
+		isUntil = p.next().Kind == lexer.Until
+		condition = parseExpr(p, assignment)
+	}
+	return condition, isUntil
+}
+
+func parseDoStmt(p *parser) ast.Stmt {
+	start := p.pos()
+	p.expect(lexer.Do)
+	condition, isUntil := parseLoopCondition(p)
+	p.expectEndOfStmt()
+	body := parseBlockStmt(p, lexer.Loop)
+	p.expect(lexer.Loop)
+
+	isPostTest := false
+	if condition == nil {
+		condition, isUntil = parseLoopCondition(p)
+		isPostTest = condition != nil
+	}
+	p.expectEndOfStmt()
+
+	return ast.DoStmt{
+		Span:       p.spanFrom(start),
+		Condition:  condition,
+		IsPostTest: isPostTest,
+		IsUntil:    isUntil,
+		Body:       body,
+	}
+}
+
+func parseWhileStmt(p *parser) ast.Stmt {
+	start := p.pos()
+	p.expect(lexer.While)
+	condition := parseExpr(p, assignment)
+	p.expectEndOfStmt()
+	body := parseBlockStmt(p, lexer.Wend)
+	p.expect(lexer.Wend)
+	p.expectEndOfStmt()
+
+	return ast.WhileStmt{
+		Span:      p.spanFrom(start),
+		Condition: condition,
+		Body:      body,
+	}
 }
 
 func parseForStmt(p *parser) ast.Stmt {
+	forStart := p.pos()
 	p.expect(lexer.For)
 	identifier := p.expect(lexer.Identifier).Value
 	p.expect(lexer.Equal)
@@ -368,16 +629,17 @@ func parseForStmt(p *parser) ast.Stmt {
 		p.next()
 		step = parseExpr(p, assignment)
 	}
-	p.expectOrEof(lexer.LineBreak)
+	p.expectEndOfStmt()
 	body := parseBlockStmt(p, lexer.Next)
 	p.expect(lexer.Next)
 	next := p.expect(lexer.Identifier).Value
 	if next != identifier {
 		panic("Next identifier must match For identifier")
 	}
-	p.expectOrEof(lexer.LineBreak)
+	p.expectEndOfStmt()
 
 	return ast.ForStmt{
+		Span:       p.spanFrom(forStart),
 		Identifier: identifier,
 		Start:      start,
 		End:        end,
@@ -386,25 +648,226 @@ func parseForStmt(p *parser) ast.Stmt {
 	}
 }
 
-func parseSubStmt(p *parser) ast.Stmt {
+func parseSubStmt(p *parser, start int, modifiers procModifiers) ast.Stmt {
 	p.expect(lexer.Sub)
 	identifier := p.expect(lexer.Identifier).Value
 	p.expect(lexer.LParen)
 	args := parseArgList(p)
 	p.expect(lexer.RParen)
-	p.expectOrEof(lexer.LineBreak)
+	p.expectEndOfStmt()
 	body := parseBlockStmt(p, lexer.EndSub)
 	p.expect(lexer.EndSub)
 
 	return ast.SubStmt{
+		Span:       p.spanFrom(start),
+		Public:     modifiers.Public,
+		Friend:     modifiers.Friend,
+		Static:     modifiers.Static,
 		Identifier: identifier,
 		Args:       args,
 		Body:       body,
 	}
 }
 
+var propertyKindMap = map[lexer.Kind]ast.PropertyKind{
+	lexer.PropertyGet: ast.PropertyGet,
+	lexer.PropertyLet: ast.PropertyLet,
+	lexer.PropertySet: ast.PropertySet,
+}
+
+func parsePropertyStmt(p *parser, start int, modifiers procModifiers) ast.Stmt {
+	kind := propertyKindMap[p.next().Kind]
+	name := p.expect(lexer.Identifier)
+	p.expect(lexer.LParen)
+	args := parseArgList(p)
+	p.expect(lexer.RParen)
+
+	// Only a Property Get returns a value; Let and Set take the new value as
+	// their last argument.
+	var returnType ast.TypeExpr
+	if kind == ast.PropertyGet {
+		returnType = parseDeclTypeExpr(p, name)
+	}
+
+	p.expectEndOfStmt()
+	body := parseBlockStmt(p, lexer.EndProperty)
+	p.expect(lexer.EndProperty)
+
+	return ast.PropertyStmt{
+		Span:       p.spanFrom(start),
+		Kind:       kind,
+		Public:     modifiers.Public,
+		Friend:     modifiers.Friend,
+		Static:     modifiers.Static,
+		Identifier: name.Value,
+		Args:       args,
+		ReturnType: returnType,
+		Body:       body,
+	}
+}
+
 func parseOptionExplicitStmt(p *parser) ast.Stmt {
+	start := p.pos()
 	p.expect(lexer.OptionExplicit)
-	p.expectOrEof(lexer.LineBreak)
-	return ast.ExitFunctionStmt{}
+	p.expectEndOfStmt()
+	return ast.OptionExplicitStmt{Span: p.spanFrom(start)}
+}
+
+// parseConditionalCompilationStmt parses an #If block that the preprocessor
+// left in place. Every branch must hold whole statements; a branch that only
+// opens or closes a procedure cannot be represented and fails to parse.
+func parseConditionalCompilationStmt(p *parser) ast.Stmt {
+	start := p.pos()
+	p.expect(lexer.HashIf)
+	condition := parseExpr(p, assignment)
+	p.expect(lexer.Then)
+	p.expectEndOfStmt()
+	body := parseBlockStmt(p, lexer.HashElseIf, lexer.HashElse, lexer.HashEndIf)
+
+	var elseIf []ast.ElseIfStmt
+	for p.peek() == lexer.HashElseIf {
+		elseIfStart := p.pos()
+		p.next()
+		elseIfCondition := parseExpr(p, assignment)
+		p.expect(lexer.Then)
+		p.expectEndOfStmt()
+		elseIfBody := parseBlockStmt(p, lexer.HashElseIf, lexer.HashElse, lexer.HashEndIf)
+		elseIf = append(elseIf, ast.ElseIfStmt{
+			Span:      p.spanFrom(elseIfStart),
+			Condition: elseIfCondition,
+			Body:      elseIfBody,
+		})
+	}
+
+	var elseBody ast.BlockStmt
+	if p.peek() == lexer.HashElse {
+		p.next()
+		elseBody = parseBlockStmt(p, lexer.HashEndIf)
+	}
+
+	p.expect(lexer.HashEndIf)
+
+	return ast.ConditionalCompilationStmt{
+		Span:      p.spanFrom(start),
+		Condition: condition,
+		Body:      body,
+		ElseIf:    elseIf,
+		Else:      elseBody,
+	}
+}
+
+func parseConditionalConstStmt(p *parser) ast.Stmt {
+	start := p.pos()
+	p.expect(lexer.HashConst)
+	identifier := p.expect(lexer.Identifier).Value
+	p.expect(lexer.Equal)
+	value := parseExpr(p, assignment)
+	p.expectEndOfStmt()
+
+	return ast.ConditionalConstStmt{
+		Span:       p.spanFrom(start),
+		Identifier: identifier,
+		Value:      value,
+	}
+}
+
+func parseWithStmt(p *parser) ast.Stmt {
+	start := p.pos()
+	p.expect(lexer.With)
+	object := parseExpr(p, assignment)
+	p.expectEndOfStmt()
+
+	p.WithDepth++
+	body := parseBlockStmt(p, lexer.EndWith)
+	p.WithDepth--
+
+	p.expect(lexer.EndWith)
+
+	return ast.WithStmt{
+		Span:   p.spanFrom(start),
+		Object: object,
+		Body:   body,
+	}
+}
+
+func parseSelectStmt(p *parser) ast.Stmt {
+	start := p.pos()
+	p.expect(lexer.Select)
+	p.expect(lexer.Case)
+	expr := parseExpr(p, assignment)
+	p.expectEndOfStmt()
+	p.skipSeparators()
+
+	cases := make([]ast.CaseClause, 0)
+	for p.peek() == lexer.Case {
+		clause := parseCaseClause(p)
+		cases = append(cases, clause)
+		if clause.IsElse {
+			break
+		}
+	}
+
+	p.expect(lexer.EndSelect)
+
+	return ast.SelectStmt{
+		Span:  p.spanFrom(start),
+		Expr:  expr,
+		Cases: cases,
+	}
+}
+
+func parseCaseClause(p *parser) ast.CaseClause {
+	start := p.pos()
+	p.expect(lexer.Case)
+
+	if p.peek() == lexer.Else {
+		p.next()
+		body := parseBlockStmt(p, lexer.EndSelect)
+		return ast.CaseClause{Span: p.spanFrom(start), IsElse: true, Body: body}
+	}
+
+	tests := make([]ast.Expr, 0)
+	for {
+		tests = append(tests, parseCaseTestExpr(p))
+		if p.peek() != lexer.Comma {
+			break
+		}
+		p.next()
+	}
+	p.expectEndOfStmt()
+
+	body := parseBlockStmt(p, lexer.Case, lexer.EndSelect)
+
+	return ast.CaseClause{
+		Span:  p.spanFrom(start),
+		Tests: tests,
+		Body:  body,
+	}
+}
+
+// parseCaseTestExpr parses a single test of a Case clause: a value, a range
+// such as 1 To 5, or a comparison such as Is > 10.
+func parseCaseTestExpr(p *parser) ast.Expr {
+	start := p.pos()
+
+	if p.peek() == lexer.Is {
+		p.next()
+		operator := p.next()
+		switch operator.Kind {
+		case lexer.Equal, lexer.NotEqual, lexer.LessThan, lexer.LessThanOrEqual, lexer.GreaterThan, lexer.GreaterThanOrEqual:
+		default:
+			panic(unexpectedToken(operator))
+		}
+		value := parseExpr(p, assignment)
+		return ast.CaseIsExpr{Span: p.spanFrom(start), Operator: operator, Value: value}
+	}
+
+	value := parseExpr(p, assignment)
+	if p.peek() != lexer.To {
+		return value
+	}
+
+	p.next()
+	upper := parseExpr(p, assignment)
+	return ast.RangeExpr{Span: p.spanFrom(start), LBound: value, UBound: upper}
 }
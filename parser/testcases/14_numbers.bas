@@ -0,0 +1,10 @@
+Public Const COLOR_RED = &HFF&
+Public Const MASK = &HFFFF
+Public Const PERMISSIONS = &O755
+Public Const PI = 3.14159
+Public Const HALF = .5
+Public Const BIG = 1E+10
+Public Const SMALL = 2.5D-3
+Public Const TIMER_INTERVAL = 10#
+Public Const MAX_GOLD = 100000&
+Public Const PRICE = 9.99@
@@ -0,0 +1,3 @@
+Public Const MILLENNIUM = #1/1/2000#
+Public Const NOON = #12:00 PM#
+Public Const DEADLINE = #12/31/1999 11:59 PM#
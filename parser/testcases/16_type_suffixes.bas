@@ -0,0 +1,7 @@
+Dim sName$
+Dim i%, Total&, Ratio!, Precise#, Price@
+Dim Anything
+
+Function Pad$(ByVal s$, ByVal n%)
+    Pad$ = s$ & Space$(n% - Len(s$))
+End Function
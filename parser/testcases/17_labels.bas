@@ -0,0 +1,10 @@
+Sub Main()
+    Dim x As Integer: Dim y As Integer
+    x = 1: y = 2
+
+ErrHandler:
+    x = 3
+10  y = 4
+20: x = 5
+30
+End Sub
@@ -0,0 +1,12 @@
+Type PlayerRec
+    Name As String * 20
+    Type As Byte
+    Class As Byte
+    Loop As Integer
+End Type
+
+Sub Main()
+    Player(Index).Type = Player(Index).Class
+    x = rs.Fields + Me.Type + obj.Loop
+    [End] = [Select] + 1
+End Sub
@@ -0,0 +1,15 @@
+If frm Is Nothing Then
+    Call ShowMain(frm)
+End If
+
+If FileName Like "*.ini" Or FileName Like "*.INI" Then
+    Call LoadSettings(FileName)
+End If
+
+If TypeOf ctl Is VB.TextBox And ctl.Enabled Then
+    ctl.Text = ""
+End If
+
+If a Eqv b Imp c Then
+    PrevProc = SetWindowLong(hWnd, GWL_WNDPROC, AddressOf modSubclass.WindowProc)
+End If
@@ -0,0 +1,8 @@
+If Not IsConnected(i) Then
+    x = -1
+    y = -x ^ 2 + +3
+End If
+
+If Not a = b And Not (c Or d) Then
+    z = -(x - 1) * -2
+End If
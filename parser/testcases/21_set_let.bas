@@ -0,0 +1,9 @@
+Sub OpenTables()
+    Set db = OpenDatabase(App.Path & "\data.mdb")
+    Set rs = db.OpenRecordset("SELECT * FROM Accounts")
+    Set Player(Index).Inventory = New Collection
+    Let Count = rs.RecordCount
+    GameWeather = WEATHER_NONE
+    IsEmpty = Count = 0
+    Set rs = Nothing
+End Sub
@@ -0,0 +1,16 @@
+Sub HandleData(ByVal Index As Long, ByVal PacketType As Long)
+    Select Case PacketType
+        Case CLogin
+            Call HandleLogin(Index)
+        Case CNewChar, CDelChar
+            Call HandleCharacter(Index, PacketType)
+        Case 10 To 19, 25
+            Call HandleMovement(Index)
+        Case Is >= 100: Call HandleAdmin(Index)
+        Case Else
+            Select Case Player(Index).Access
+                Case Is > ADMIN_MONITOR
+                    Call HackingAttempt(Index, "Invalid Packet")
+            End Select
+    End Select
+End Sub
@@ -0,0 +1,22 @@
+Sub ServerLoop()
+    Do While ServerOnline
+        Tick = GetTickCount
+        Do Until i > MAX_PLAYERS
+            i = i + 1
+        Loop
+        Do
+            n = n + 1
+        Loop While n < 10
+        Do
+            Call Sleep(1)
+        Loop Until Tick > LastTick + 1000
+        Do
+            If Not IsConnected(n) Then
+                Exit Do
+            End If
+        Loop
+        While Not EOF(1)
+            Line = ReadLine(1)
+        Wend
+    Loop
+End Sub
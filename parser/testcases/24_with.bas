@@ -0,0 +1,15 @@
+Sub ResetCharacter(ByVal Index As Long, ByVal CharNum As Long)
+    With Player(Index).Char(CharNum)
+        .Name = vbNullString
+        .Level = 1
+        .Stat(STR) = .Stat(STR) + 1
+        With .Inv(1)
+            .Num = 0
+            .Value = 0
+        End With
+        Total = .Gold + .Bank
+        Set Conn = Server _
+            .Connection
+        Call SendPlayerData(Index)
+    End With
+End Sub
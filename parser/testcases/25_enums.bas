@@ -0,0 +1,22 @@
+Option Explicit
+
+Public Enum PlayerAccess
+    ACCESS_NONE
+    ACCESS_MONITOR = 1
+    ACCESS_MAPPER
+    ACCESS_DEVELOPER
+    ACCESS_ADMIN = 4
+End Enum
+
+Private Enum Direction
+    [DIR_UP] = -1
+
+    ' Comments and blank lines between members are skipped
+    DIR_DOWN
+    DIR_LEFT = DIR_UP Or 2: DIR_RIGHT
+End Enum
+
+Enum Stats
+    Strength
+    Endurance
+End Enum
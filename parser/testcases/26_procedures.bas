@@ -0,0 +1,43 @@
+Option Explicit
+
+Private mName As String
+
+Public Sub Main()
+    Call InitServer(1)
+End Sub
+
+Private Sub InitServer(ByVal Port As Long)
+    Call Listen(Port)
+End Sub
+
+Public Function GetPlayerName(ByVal Index As Long) As String
+    GetPlayerName = Trim$(Player(Index).Name)
+End Function
+
+Private Static Function NextId() As Long
+    Counter = Counter + 1
+    NextId = Counter
+End Function
+
+Friend Sub SetOwner(ByVal Owner As Long)
+    mOwner = Owner
+End Sub
+
+Static Sub Tick()
+    Ticks = Ticks + 1
+End Sub
+
+Public Property Get Name() As String
+    If Len(mName) = 0 Then
+        Exit Property
+    End If
+    Name = mName
+End Property
+
+Public Property Let Name(ByVal Value As String)
+    mName = Value
+End Property
+
+Friend Property Set Socket(ByVal Value As Object)
+    Set mSocket = Value
+End Property
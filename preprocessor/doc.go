@@ -0,0 +1,33 @@
+// Package preprocessor handles the conditional compilation directives of VB6
+// source code: #Const, #If, #ElseIf, #Else and #End If. It runs on the tokens
+// produced by the lexer, before they are handed to the parser.
+//
+// # Example Usage
+//
+// Given the following VB6 source code:
+//
+//	#Const LOGGING = DEBUG_MODE
+//	#If LOGGING Then
+//	    Call Log("starting")
+//	#End If
+//
+// The debug variant of the module is parsed as follows:
+//
+//	tokens := lexer.Tokenize(source)
+//	tokens, diagnostics := preprocessor.Preprocess(tokens, preprocessor.Options{
+//	    Constants: map[string]any{"DEBUG_MODE": 1},
+//	})
+//	block := parser.Parse(tokens)
+//
+// Constants that are never defined are Empty, which counts as false, so
+// without DEBUG_MODE the call to Log is left out.
+//
+// # Keeping All Branches
+//
+// Tools such as formatters need to see every branch. With
+// Options.KeepBranches set, the directives stay in the token stream and the
+// parser records them as ast.ConditionalCompilationStmt and
+// ast.ConditionalConstStmt. This only works when every branch holds whole
+// statements; an #If that switches between two different headers for the
+// same Sub cannot be represented in the AST.
+package preprocessor
@@ -0,0 +1,332 @@
+package preprocessor
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/guthius/vb6/lexer"
+)
+
+// The evaluator computes the value of a conditional compilation expression.
+// Values are float64 or string. As in VB6, True is -1 and False is 0, and an
+// undefined constant is Empty (nil), which acts as 0 or as an empty string
+// depending on where it is used.
+//
+// Operators follow the VB6 precedence, from low to high: Imp, Eqv, Xor, Or,
+// And, Not, comparisons, &, + and -, Mod, \, * and /, negation and ^.
+type evaluator struct {
+	Tokens    []lexer.Token
+	Pos       int
+	Constants map[string]any
+}
+
+// evalError is raised with panic when an expression cannot be evaluated.
+type evalError struct {
+	Token   lexer.Token
+	Message string
+}
+
+func (e *evaluator) evaluate() any {
+	v := e.imp()
+	if e.Pos < len(e.Tokens) {
+		e.fail(e.Tokens[e.Pos], "unexpected "+lexer.TokenKindString(e.Tokens[e.Pos].Kind))
+	}
+	return v
+}
+
+func (e *evaluator) fail(tok lexer.Token, message string) {
+	panic(evalError{Token: tok, Message: message})
+}
+
+func (e *evaluator) peek() lexer.Kind {
+	if e.Pos >= len(e.Tokens) {
+		return lexer.EOF
+	}
+	return e.Tokens[e.Pos].Kind
+}
+
+func (e *evaluator) next() lexer.Token {
+	if e.Pos >= len(e.Tokens) {
+		e.fail(e.Tokens[len(e.Tokens)-1], "unexpected end of expression")
+	}
+	e.Pos++
+	return e.Tokens[e.Pos-1]
+}
+
+// binary evaluates a chain of left-associative operators of the same
+// precedence, with operands of higher precedence.
+func (e *evaluator) binary(operand func() any, kinds ...lexer.Kind) any {
+	left := operand()
+	for slices.Contains(kinds, e.peek()) {
+		op := e.next()
+		left = e.apply(op, left, operand())
+	}
+	return left
+}
+
+func (e *evaluator) imp() any {
+	return e.binary(e.eqv, lexer.Imp)
+}
+
+func (e *evaluator) eqv() any {
+	return e.binary(e.xor, lexer.Eqv)
+}
+
+func (e *evaluator) xor() any {
+	return e.binary(e.or, lexer.Xor)
+}
+
+func (e *evaluator) or() any {
+	return e.binary(e.and, lexer.Or)
+}
+
+func (e *evaluator) and() any {
+	return e.binary(e.not, lexer.And)
+}
+
+func (e *evaluator) not() any {
+	if e.peek() == lexer.Not {
+		op := e.next()
+		return float64(^e.integer(op, e.not()))
+	}
+	return e.comparison()
+}
+
+func (e *evaluator) comparison() any {
+	return e.binary(e.concat,
+		lexer.Equal, lexer.NotEqual,
+		lexer.LessThan, lexer.LessThanOrEqual,
+		lexer.GreaterThan, lexer.GreaterThanOrEqual)
+}
+
+func (e *evaluator) concat() any {
+	return e.binary(e.additive, lexer.Concat)
+}
+
+func (e *evaluator) additive() any {
+	return e.binary(e.modulus, lexer.Add, lexer.Subtract)
+}
+
+func (e *evaluator) modulus() any {
+	return e.binary(e.intDivision, lexer.Modulus)
+}
+
+func (e *evaluator) intDivision() any {
+	return e.binary(e.multiplicative, lexer.DivideInt)
+}
+
+func (e *evaluator) multiplicative() any {
+	return e.binary(e.negation, lexer.Multiply, lexer.Divide)
+}
+
+func (e *evaluator) negation() any {
+	switch e.peek() {
+	case lexer.Subtract:
+		op := e.next()
+		return -e.number(op, e.negation())
+	case lexer.Add:
+		op := e.next()
+		return e.number(op, e.negation())
+	}
+	return e.exponent()
+}
+
+// exponent evaluates a chain of ^ operators. The exponent itself may be
+// negated, as in 2 ^ -1.
+func (e *evaluator) exponent() any {
+	left := e.primary()
+	for e.peek() == lexer.Exponent {
+		op := e.next()
+		var right any
+		if e.peek() == lexer.Subtract || e.peek() == lexer.Add {
+			right = e.negation()
+		} else {
+			right = e.primary()
+		}
+		left = e.apply(op, left, right)
+	}
+	return left
+}
+
+func (e *evaluator) primary() any {
+	tok := e.next()
+	switch tok.Kind {
+	case lexer.Number:
+		return e.numberLiteral(tok)
+	case lexer.String:
+		return tok.Value
+	case lexer.Identifier:
+		if v, ok := e.Constants[strings.ToLower(tok.Value)]; ok {
+			return v
+		}
+		switch strings.ToLower(tok.Value) {
+		case "true":
+			return boolValue(true)
+		case "false":
+			return boolValue(false)
+		}
+		return nil
+	case lexer.LParen:
+		v := e.imp()
+		if e.peek() != lexer.RParen {
+			e.fail(tok, "missing )")
+		}
+		e.next()
+		return v
+	}
+
+	e.fail(tok, lexer.TokenKindString(tok.Kind)+" is not allowed in a conditional compilation expression")
+	return nil
+}
+
+func (e *evaluator) numberLiteral(tok lexer.Token) any {
+	if tok.Radix == 16 || tok.Radix == 8 {
+		v, err := strconv.ParseUint(tok.Value, tok.Radix, 32)
+		if err != nil {
+			e.fail(tok, "invalid number "+tok.Value)
+		}
+		if tok.Suffix != lexer.LongSuffix && v <= math.MaxUint16 {
+			return float64(int16(v))
+		}
+		return float64(int32(v))
+	}
+
+	v, err := strconv.ParseFloat(strings.NewReplacer("d", "e", "D", "e").Replace(tok.Value), 64)
+	if err != nil {
+		e.fail(tok, "invalid number "+tok.Value)
+	}
+	return v
+}
+
+func (e *evaluator) apply(op lexer.Token, left, right any) any {
+	switch op.Kind {
+	case lexer.Concat:
+		return e.text(left) + e.text(right)
+	case lexer.Add:
+		if l, ok := left.(string); ok {
+			if r, ok := right.(string); ok {
+				return l + r
+			}
+		}
+		return e.number(op, left) + e.number(op, right)
+	case lexer.Subtract:
+		return e.number(op, left) - e.number(op, right)
+	case lexer.Multiply:
+		return e.number(op, left) * e.number(op, right)
+	case lexer.Divide:
+		r := e.number(op, right)
+		if r == 0 {
+			e.fail(op, "division by zero")
+		}
+		return e.number(op, left) / r
+	case lexer.DivideInt, lexer.Modulus:
+		l, r := e.integer(op, left), e.integer(op, right)
+		if r == 0 {
+			e.fail(op, "division by zero")
+		}
+		if op.Kind == lexer.Modulus {
+			return float64(l % r)
+		}
+		return float64(l / r)
+	case lexer.Exponent:
+		return math.Pow(e.number(op, left), e.number(op, right))
+	case lexer.And:
+		return float64(e.integer(op, left) & e.integer(op, right))
+	case lexer.Or:
+		return float64(e.integer(op, left) | e.integer(op, right))
+	case lexer.Xor:
+		return float64(e.integer(op, left) ^ e.integer(op, right))
+	case lexer.Eqv:
+		return float64(^(e.integer(op, left) ^ e.integer(op, right)))
+	case lexer.Imp:
+		return float64(^e.integer(op, left) | e.integer(op, right))
+	default:
+		return boolValue(e.compare(op, left, right))
+	}
+}
+
+// compare applies a comparison operator. Strings are compared to strings and
+// numbers to numbers; Empty matches either.
+func (e *evaluator) compare(op lexer.Token, left, right any) bool {
+	_, ls := left.(string)
+	_, rs := right.(string)
+
+	var c int
+	if ls || rs {
+		c = strings.Compare(e.text(left), e.text(right))
+		if (ls && right != nil && !rs) || (rs && left != nil && !ls) {
+			e.fail(op, "type mismatch")
+		}
+	} else {
+		l, r := e.number(op, left), e.number(op, right)
+		switch {
+		case l < r:
+			c = -1
+		case l > r:
+			c = 1
+		}
+	}
+
+	switch op.Kind {
+	case lexer.Equal:
+		return c == 0
+	case lexer.NotEqual:
+		return c != 0
+	case lexer.LessThan:
+		return c < 0
+	case lexer.LessThanOrEqual:
+		return c <= 0
+	case lexer.GreaterThan:
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+func (e *evaluator) number(op lexer.Token, v any) float64 {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case float64:
+		return v
+	}
+	e.fail(op, "type mismatch")
+	return 0
+}
+
+// integer converts a value to a whole number the way VB6 converts to Long,
+// rounding halves to even.
+func (e *evaluator) integer(op lexer.Token, v any) int64 {
+	return int64(math.RoundToEven(e.number(op, v)))
+}
+
+func (e *evaluator) text(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return v.(string)
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return -1
+	}
+	return 0
+}
+
+// truth reports whether a condition holds. Any number other than zero is
+// true.
+func truth(v any) bool {
+	switch v := v.(type) {
+	case float64:
+		return v != 0
+	default:
+		return false
+	}
+}
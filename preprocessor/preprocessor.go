@@ -0,0 +1,309 @@
+package preprocessor
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/guthius/vb6/lexer"
+)
+
+// Options controls how the preprocessor treats conditional compilation
+// directives.
+type Options struct {
+	// Constants holds the constants defined outside of the source, like the
+	// conditional compilation arguments of a VB6 project. Values may be
+	// booleans, numbers of any integer or floating point type, or strings.
+	// A constant of any other type is reported without a position and left
+	// undefined. Names are not case-sensitive.
+	Constants map[string]any
+
+	// KeepBranches leaves all directives and all branches in place instead of
+	// evaluating them, so that the parser records them as
+	// ast.ConditionalCompilationStmt and ast.ConditionalConstStmt. The
+	// directives are still checked for balance.
+	KeepBranches bool
+}
+
+// Preprocess evaluates the conditional compilation directives in the tokens.
+// It returns the tokens of the branches that are compiled, without the
+// directives themselves. Tokens that are removed take their trivia with
+// them.
+//
+// Like the lexer, Preprocess does not stop at the first problem. A condition
+// that cannot be evaluated is reported and treated as false.
+func Preprocess(tokens []lexer.Token, options Options) ([]lexer.Token, []lexer.Diagnostic) {
+	pp := &preprocessor{
+		Tokens:      tokens,
+		Output:      make([]lexer.Token, 0, len(tokens)),
+		Diagnostics: make([]lexer.Diagnostic, 0),
+		Options:     options,
+		Constants:   make(map[string]any, len(options.Constants)),
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(options.Constants)) {
+		v, ok := constantValue(options.Constants[name])
+		if !ok {
+			pp.Diagnostics = append(pp.Diagnostics, lexer.Diagnostic{
+				Message: fmt.Sprintf("unsupported type %T for constant %s", options.Constants[name], name),
+			})
+			continue
+		}
+		pp.Constants[strings.ToLower(name)] = v
+	}
+
+	pp.run()
+	return pp.Output, pp.Diagnostics
+}
+
+type preprocessor struct {
+	Tokens      []lexer.Token
+	Pos         int
+	Output      []lexer.Token
+	Diagnostics []lexer.Diagnostic
+	Options     Options
+
+	// Constants holds the value of every constant defined so far, keyed by
+	// its lower case name.
+	Constants map[string]any
+
+	// Blocks holds the #If blocks that are open at the current position,
+	// innermost last.
+	Blocks []block
+}
+
+// block is an #If block that has not been closed yet.
+type block struct {
+	// Start is the #If directive that opened the block.
+	Start lexer.Token
+
+	// Active is set while the tokens of the current branch are compiled.
+	Active bool
+
+	// Taken is set once a branch of the block has been selected, after which
+	// the remaining branches are skipped.
+	Taken bool
+
+	// HasElse is set once the #Else branch has been reached.
+	HasElse bool
+}
+
+func (pp *preprocessor) run() {
+	for pp.Pos < len(pp.Tokens) {
+		tok := pp.Tokens[pp.Pos]
+		switch tok.Kind {
+		case lexer.HashIf, lexer.HashElseIf, lexer.HashElse, lexer.HashEndIf, lexer.HashConst:
+			pp.directive()
+			continue
+		}
+
+		if tok.Kind == lexer.EOF || pp.Options.KeepBranches || pp.active() {
+			pp.Output = append(pp.Output, tok)
+		}
+		pp.Pos++
+	}
+
+	for _, b := range pp.Blocks {
+		pp.errorf(b.Start, "#If without #End If")
+	}
+}
+
+// active reports whether the tokens at the current position are compiled.
+func (pp *preprocessor) active() bool {
+	return len(pp.Blocks) == 0 || pp.Blocks[len(pp.Blocks)-1].Active
+}
+
+// directive handles the directive at the current position and consumes the
+// rest of its line, including the line break.
+func (pp *preprocessor) directive() {
+	start := pp.Pos
+	tok := pp.Tokens[pp.Pos]
+	pp.Pos++
+
+	lineStart := pp.Pos
+	for pp.Pos < len(pp.Tokens) && pp.Tokens[pp.Pos].Kind != lexer.LineBreak && pp.Tokens[pp.Pos].Kind != lexer.EOF {
+		pp.Pos++
+	}
+	line := pp.Tokens[lineStart:pp.Pos]
+	if pp.Pos < len(pp.Tokens) && pp.Tokens[pp.Pos].Kind == lexer.LineBreak {
+		pp.Pos++
+	}
+
+	if pp.Options.KeepBranches {
+		pp.Output = append(pp.Output, pp.Tokens[start:pp.Pos]...)
+	}
+
+	switch tok.Kind {
+	case lexer.HashConst:
+		pp.constDirective(tok, line)
+	case lexer.HashIf:
+		pp.ifDirective(tok, line)
+	case lexer.HashElseIf:
+		pp.elseIfDirective(tok, line)
+	case lexer.HashElse:
+		pp.elseDirective(tok, line)
+	case lexer.HashEndIf:
+		pp.endIfDirective(tok, line)
+	}
+}
+
+func (pp *preprocessor) constDirective(tok lexer.Token, line []lexer.Token) {
+	if len(line) < 3 || line[0].Kind != lexer.Identifier || line[1].Kind != lexer.Equal {
+		pp.errorf(tok, "expected #Const name = value")
+		return
+	}
+	if pp.Options.KeepBranches || !pp.active() {
+		return
+	}
+
+	if v, ok := pp.evaluate(line[2:]); ok {
+		pp.Constants[strings.ToLower(line[0].Value)] = v
+	}
+}
+
+func (pp *preprocessor) ifDirective(tok lexer.Token, line []lexer.Token) {
+	b := block{Start: tok}
+	if pp.active() {
+		b.Active = pp.condition(tok, line)
+		b.Taken = b.Active
+	} else {
+		// None of the branches of a block inside a skipped branch are
+		// compiled.
+		b.Taken = true
+	}
+	pp.Blocks = append(pp.Blocks, b)
+}
+
+func (pp *preprocessor) elseIfDirective(tok lexer.Token, line []lexer.Token) {
+	b := pp.current(tok)
+	if b == nil {
+		return
+	}
+	if b.HasElse {
+		pp.errorf(tok, "#ElseIf after #Else")
+	}
+
+	b.Active = false
+	if !b.Taken {
+		b.Active = pp.condition(tok, line)
+		b.Taken = b.Active
+	}
+}
+
+func (pp *preprocessor) elseDirective(tok lexer.Token, line []lexer.Token) {
+	pp.expectEndOfLine(tok, line)
+	b := pp.current(tok)
+	if b == nil {
+		return
+	}
+	if b.HasElse {
+		pp.errorf(tok, "#Else after #Else")
+	}
+
+	b.Active = !b.Taken
+	b.Taken = true
+	b.HasElse = true
+}
+
+func (pp *preprocessor) endIfDirective(tok lexer.Token, line []lexer.Token) {
+	pp.expectEndOfLine(tok, line)
+	if pp.current(tok) == nil {
+		return
+	}
+	pp.Blocks = pp.Blocks[:len(pp.Blocks)-1]
+}
+
+// current returns the innermost open #If block, or reports the directive as
+// misplaced if there is none.
+func (pp *preprocessor) current(tok lexer.Token) *block {
+	if len(pp.Blocks) == 0 {
+		pp.errorf(tok, "%s without #If", directiveName(tok.Kind))
+		return nil
+	}
+	return &pp.Blocks[len(pp.Blocks)-1]
+}
+
+// condition evaluates the condition of an #If or #ElseIf directive, which is
+// followed by Then. When branches are kept, every branch is reported as
+// inactive so that only the directive structure is checked.
+func (pp *preprocessor) condition(tok lexer.Token, line []lexer.Token) bool {
+	if len(line) < 2 || line[len(line)-1].Kind != lexer.Then {
+		pp.errorf(tok, "expected %s condition Then", directiveName(tok.Kind))
+		return false
+	}
+	if pp.Options.KeepBranches {
+		return false
+	}
+
+	v, ok := pp.evaluate(line[:len(line)-1])
+	if !ok {
+		return false
+	}
+	return truth(v)
+}
+
+func (pp *preprocessor) expectEndOfLine(tok lexer.Token, line []lexer.Token) {
+	if len(line) > 0 {
+		pp.errorf(line[0], "unexpected %s after %s", lexer.TokenKindString(line[0].Kind), directiveName(tok.Kind))
+	}
+}
+
+// directiveName returns the name of a directive as it is written in the
+// source, for use in diagnostics.
+func directiveName(kind lexer.Kind) string {
+	if kind == lexer.HashEndIf {
+		return "#End If"
+	}
+	return lexer.TokenKindString(kind)
+}
+
+// evaluate evaluates an expression, reporting any problem as a diagnostic.
+func (pp *preprocessor) evaluate(tokens []lexer.Token) (v any, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			err, isEvalError := r.(evalError)
+			if !isEvalError {
+				panic(r)
+			}
+			pp.errorf(err.Token, "%s", err.Message)
+			v, ok = nil, false
+		}
+	}()
+
+	e := &evaluator{Tokens: tokens, Constants: pp.Constants}
+	return e.evaluate(), true
+}
+
+func (pp *preprocessor) errorf(tok lexer.Token, format string, args ...any) {
+	pp.Diagnostics = append(pp.Diagnostics, lexer.Diagnostic{
+		Message: fmt.Sprintf(format, args...),
+		Line:    tok.Line,
+		Column:  tok.Column,
+	})
+}
+
+// constantValue converts a constant given by the caller to the representation
+// used by the evaluator. It reports false for a value of any other type.
+func constantValue(v any) (any, bool) {
+	if v == nil {
+		return nil, true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), true
+	case reflect.Bool:
+		return boolValue(rv.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return nil, false
+	}
+}
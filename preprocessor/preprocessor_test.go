@@ -0,0 +1,170 @@
+package preprocessor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guthius/vb6/ast"
+	"github.com/guthius/vb6/lexer"
+	"github.com/guthius/vb6/parser"
+)
+
+// names returns the identifiers that survive preprocessing, separated by
+// spaces.
+func names(t *testing.T, source string, constants map[string]any) string {
+	t.Helper()
+	tokens, diagnostics := Preprocess(lexer.Tokenize(source), Options{Constants: constants})
+	if len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics %v", diagnostics)
+	}
+
+	var names []string
+	for _, tok := range tokens {
+		if tok.Kind == lexer.Identifier {
+			names = append(names, tok.Value)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func TestPreprocessBranches(t *testing.T) {
+	source := "a\n#If DEBUG_MODE = 1 Then\nb\n#ElseIf DEBUG_MODE = 2 Then\nc\n#Else\nd\n#End If\ne\n"
+
+	cases := []struct {
+		value    any
+		expected string
+	}{
+		{1, "a b e"},
+		{2, "a c e"},
+		{3, "a d e"},
+		{nil, "a d e"},
+	}
+
+	for _, c := range cases {
+		if actual := names(t, source, map[string]any{"debug_mode": c.value}); actual != c.expected {
+			t.Errorf("DEBUG_MODE = %v: expected %q, got %q", c.value, c.expected, actual)
+		}
+	}
+}
+
+func TestPreprocessNestedAndConst(t *testing.T) {
+	source := `#Const LOGGING = DEBUG_MODE And Not QUIET
+#If LOGGING Then
+    a
+    #If VERBOSE Then
+        b
+    #Else
+        c
+    #End If
+#Else
+    #If True Then
+        d
+    #End If
+#End If
+`
+
+	if actual := names(t, source, map[string]any{"DEBUG_MODE": true}); actual != "a c" {
+		t.Errorf("expected %q, got %q", "a c", actual)
+	}
+	if actual := names(t, source, map[string]any{"DEBUG_MODE": true, "QUIET": true}); actual != "d" {
+		t.Errorf("expected %q, got %q", "d", actual)
+	}
+}
+
+func TestPreprocessConstantTypes(t *testing.T) {
+	type version uint16
+
+	constants := map[string]any{
+		"A": int64(1),
+		"B": int32(-2),
+		"C": uint(3),
+		"D": float32(0.5),
+		"E": version(6),
+	}
+	source := "#If A = 1 And B = -2 And C = 3 And D = 0.5 And E = 6 Then\nyes\n#End If"
+	if actual := names(t, source, constants); actual != "yes" {
+		t.Errorf("expected the condition to hold")
+	}
+
+	_, diagnostics := Preprocess(lexer.Tokenize("#If X Then\n#End If"), Options{Constants: map[string]any{"X": []int{1}}})
+	if len(diagnostics) != 1 || diagnostics[0].Message != "unsupported type []int for constant X" {
+		t.Errorf("expected a diagnostic for the constant, got %v", diagnostics)
+	}
+}
+
+func TestPreprocessExpressions(t *testing.T) {
+	cases := []string{
+		"1 + 2 * 3 = 7",
+		"-2 ^ 2 = -4",
+		"2 ^ -1 = 0.5",
+		"10 \\ 3 = 3",
+		"7 Mod 3 = 1",
+		"(1 + 2) * 3 = 9",
+		"\"VB\" & 6 = \"VB6\"",
+		"\"a\" < \"b\"",
+		"UNDEFINED = 0 And UNDEFINED = \"\"",
+		"Not 0",
+		"(3 And 6) = 2",
+		"(1 Or 0 Xor 1) = 0",
+		"&HFFFF = -1",
+		"(0 Eqv 0) = -1",
+		"(-1 Imp 0) = 0",
+		"(0 Imp -1 Eqv 0) = -1",
+	}
+
+	for _, c := range cases {
+		t.Run(c, func(t *testing.T) {
+			if actual := names(t, "#If "+c+" Then\nyes\n#End If", nil); actual != "yes" {
+				t.Errorf("expected the condition to hold")
+			}
+		})
+	}
+}
+
+func TestPreprocessDiagnostics(t *testing.T) {
+	cases := []struct {
+		source  string
+		message string
+	}{
+		{"#If A Then\nx", "#If without #End If"},
+		{"#Else\n", "#Else without #If"},
+		{"#End If\n", "#End If without #If"},
+		{"#If A Then\n#End If x\n", "unexpected Identifier after #End If"},
+		{"#If A Then\n#Else\n#Else\n#End If", "#Else after #Else"},
+		{"#If A\n#End If", "expected #If condition Then"},
+		{"#If 1 = \"a\" Then\n#End If", "type mismatch"},
+		{"#If 1 / 0 Then\n#End If", "division by zero"},
+		{"#Const = 1", "expected #Const name = value"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.message, func(t *testing.T) {
+			_, diagnostics := Preprocess(lexer.Tokenize(c.source), Options{})
+			if len(diagnostics) != 1 || diagnostics[0].Message != c.message {
+				t.Errorf("expected %q, got %v", c.message, diagnostics)
+			}
+		})
+	}
+}
+
+func TestPreprocessKeepBranches(t *testing.T) {
+	source := "#Const DEBUG_MODE = 1\nSub Main()\n#If DEBUG_MODE Then\n    a = 1\n#ElseIf RELEASE Then\n    b = 1\n#Else\n    c = 1\n#End If\nEnd Sub\n"
+
+	tokens, diagnostics := Preprocess(lexer.Tokenize(source), Options{KeepBranches: true})
+	if len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics %v", diagnostics)
+	}
+
+	block := parser.Parse(tokens)
+	if _, ok := block[0].(ast.ConditionalConstStmt); !ok {
+		t.Fatalf("expected a ConditionalConstStmt, got %+v", block[0])
+	}
+
+	stmt := block[1].(ast.SubStmt).Body[0].(ast.ConditionalCompilationStmt)
+	if len(stmt.Body) != 1 || len(stmt.ElseIf) != 1 || len(stmt.Else) != 1 {
+		t.Errorf("expected every branch to be kept, got %+v", stmt)
+	}
+	if actual := source[stmt.Start:stmt.End]; !strings.HasPrefix(actual, "#If") || !strings.HasSuffix(actual, "#End If") {
+		t.Errorf("unexpected span %q", actual)
+	}
+}
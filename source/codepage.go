@@ -0,0 +1,52 @@
+package source
+
+// CodePage describes how the bytes of a source file map to characters. VB6
+// saves files in the ANSI code page of the machine they were written on, so
+// files from different machines may need different code pages.
+type CodePage struct {
+	Name string
+
+	// high maps the bytes 0x80-0xFF to Unicode. The bytes 0x00-0x7F are
+	// always ASCII. A nil table means the file is already UTF-8.
+	high *[128]rune
+}
+
+// NewCodePage creates a single-byte code page. The table maps the bytes
+// 0x80-0xFF, in order, to the Unicode characters they represent.
+func NewCodePage(name string, high [128]rune) *CodePage {
+	return &CodePage{Name: name, high: &high}
+}
+
+// UTF8 leaves the bytes of the file untouched.
+var UTF8 = &CodePage{Name: "UTF-8"}
+
+// Latin1 is ISO-8859-1, where every byte is the Unicode character with the
+// same value.
+var Latin1 = NewCodePage("ISO-8859-1", latin1High())
+
+// Windows1252 is the ANSI code page used for Western European languages and
+// the default for VB6 source files. It is Latin1 with printable characters
+// in place of most of the C1 control codes.
+var Windows1252 = NewCodePage("Windows-1252", windows1252High())
+
+func latin1High() [128]rune {
+	var high [128]rune
+	for i := range high {
+		high[i] = rune(0x80 + i)
+	}
+	return high
+}
+
+func windows1252High() [128]rune {
+	high := latin1High()
+
+	// The bytes 0x81, 0x8D, 0x8F, 0x90 and 0x9D are undefined and keep their
+	// C1 control code, as they do when Windows converts them.
+	copy(high[:0x20], []rune{
+		'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡',
+		'ˆ', '‰', 'Š', '‹', 'Œ', '\u008D', 'Ž', '\u008F',
+		'\u0090', '‘', '’', '“', '”', '•', '–', '—',
+		'˜', '™', 'š', '›', 'œ', '\u009D', 'ž', 'Ÿ',
+	})
+	return high
+}
@@ -0,0 +1,129 @@
+// Package source turns the raw bytes of a VB6 source file into the UTF-8 text
+// the lexer works on, and maps positions in that text back to the file.
+package source
+
+import (
+	"encoding/binary"
+	"os"
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Options controls how a source file is decoded.
+type Options struct {
+	// CodePage is used for files without a byte order mark. It defaults to
+	// Windows1252.
+	CodePage *CodePage
+}
+
+// File is a decoded source file.
+type File struct {
+	// Text is the contents of the file as UTF-8. This is what should be passed
+	// to the lexer.
+	Text string
+
+	// chars lists every character whose length in Text differs from its
+	// length in the original file, in order. Between two such characters the
+	// offsets in Text and in the file move in step.
+	chars []char
+}
+
+// char records where a character starts in the text and in the original
+// file, and how many bytes it takes in each.
+type char struct {
+	textStart, textLen int
+	origStart, origLen int
+}
+
+// ReadFile reads and decodes the named source file.
+func ReadFile(name string, options Options) (*File, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Load(b, options), nil
+}
+
+// Load decodes the contents of a source file. A UTF-16 byte order mark, little
+// or big endian, makes the rest of the file decode as UTF-16 and a UTF-8 byte
+// order mark makes it decode as UTF-8. Files without a mark are decoded using
+// the code page from the options.
+//
+// A UTF-8 byte order mark is kept in the text; the lexer skips it.
+func Load(b []byte, options Options) *File {
+	codePage := options.CodePage
+	if codePage == nil {
+		codePage = Windows1252
+	}
+
+	switch {
+	case len(b) >= 2 && b[0] == 0xFF && b[1] == 0xFE:
+		return decodeUTF16(b, binary.LittleEndian)
+	case len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF:
+		return decodeUTF16(b, binary.BigEndian)
+	case len(b) >= 3 && b[0] == 0xEF && b[1] == 0xBB && b[2] == 0xBF:
+		return &File{Text: string(b)}
+	case codePage.high == nil:
+		return &File{Text: string(b)}
+	default:
+		return decodeCodePage(b, codePage)
+	}
+}
+
+func decodeCodePage(b []byte, codePage *CodePage) *File {
+	f := &File{}
+	text := make([]byte, 0, len(b))
+	for i, c := range b {
+		if c < 0x80 {
+			text = append(text, c)
+			continue
+		}
+
+		start := len(text)
+		text = utf8.AppendRune(text, codePage.high[c-0x80])
+		f.chars = append(f.chars, char{textStart: start, textLen: len(text) - start, origStart: i, origLen: 1})
+	}
+	f.Text = string(text)
+	return f
+}
+
+func decodeUTF16(b []byte, order binary.ByteOrder) *File {
+	// The byte order mark itself does not end up in the text.
+	f := &File{chars: []char{{textStart: 0, textLen: 0, origStart: 0, origLen: 2}}}
+	text := make([]byte, 0, len(b)/2)
+	for i := 2; i < len(b); {
+		r, size := utf8.RuneError, len(b)-i
+		if size >= 2 {
+			r, size = rune(order.Uint16(b[i:])), 2
+			if utf16.IsSurrogate(r) && i+4 <= len(b) {
+				if pair := utf16.DecodeRune(r, rune(order.Uint16(b[i+2:]))); pair != utf8.RuneError {
+					r, size = pair, 4
+				}
+			}
+		}
+
+		start := len(text)
+		text = utf8.AppendRune(text, r)
+		f.chars = append(f.chars, char{textStart: start, textLen: len(text) - start, origStart: i, origLen: size})
+		i += size
+	}
+	f.Text = string(text)
+	return f
+}
+
+// OriginalOffset maps a byte offset in Text, such as the Start of a token, to
+// the byte offset in the original file. An offset inside a multi-byte
+// character maps to the start of that character.
+func (f *File) OriginalOffset(offset int) int {
+	i := sort.Search(len(f.chars), func(i int) bool { return f.chars[i].textStart > offset }) - 1
+	if i < 0 {
+		return offset
+	}
+
+	c := f.chars[i]
+	if offset < c.textStart+c.textLen {
+		return c.origStart
+	}
+	return c.origStart + c.origLen + offset - (c.textStart + c.textLen)
+}
@@ -0,0 +1,51 @@
+package source
+
+import "testing"
+
+func TestLoad(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    []byte
+		options  Options
+		expected string
+	}{
+		{"ascii", []byte("Dim x\r\n"), Options{}, "Dim x\r\n"},
+		{"windows-1252", []byte("s = \"caf\xE9 \x80\x96\""), Options{}, "s = \"café €–\""},
+		{"latin-1", []byte("\xE9\x80"), Options{CodePage: Latin1}, "é\u0080"},
+		{"utf-8 option", []byte("café"), Options{CodePage: UTF8}, "café"},
+		{"utf-8 bom", []byte("\xEF\xBB\xBFcaf\xC3\xA9"), Options{}, "\uFEFFcafé"},
+		{"utf-16 le", []byte{0xFF, 0xFE, 'D', 0, 'i', 0, 'm', 0, 0xE9, 0}, Options{}, "Dimé"},
+		{"utf-16 be", []byte{0xFE, 0xFF, 0, 'D', 0, 'i', 0, 'm', 0, 0xE9}, Options{}, "Dimé"},
+		{"utf-16 surrogate pair", []byte{0xFF, 0xFE, 0x3D, 0xD8, 0x00, 0xDE}, Options{}, "😀"},
+		{"utf-16 odd length", []byte{0xFF, 0xFE, 'x', 0, 'y'}, Options{}, "x�"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := Load(c.input, c.options).Text; actual != c.expected {
+				t.Errorf("expected %q, got %q", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestOriginalOffset(t *testing.T) {
+	// "é" is one byte in Windows-1252 and two bytes in UTF-8.
+	f := Load([]byte("a\xE9b\xE9\xE9c"), Options{})
+	expected := map[int]int{0: 0, 1: 1, 2: 1, 3: 2, 4: 3, 6: 4, 8: 5, 9: 6}
+	for offset, orig := range expected {
+		if actual := f.OriginalOffset(offset); actual != orig {
+			t.Errorf("offset %d: expected %d, got %d", offset, orig, actual)
+		}
+	}
+
+	// The byte order mark is not part of the text, and every character takes
+	// two bytes in the file.
+	f = Load([]byte{0xFF, 0xFE, 'a', 0, 0xE9, 0, 'b', 0}, Options{})
+	expected = map[int]int{0: 2, 1: 4, 3: 6, 4: 8}
+	for offset, orig := range expected {
+		if actual := f.OriginalOffset(offset); actual != orig {
+			t.Errorf("utf-16 offset %d: expected %d, got %d", offset, orig, actual)
+		}
+	}
+}